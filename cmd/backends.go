@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+)
+
+// backendList is a repeatable flag of LLM backends in priority order
+type backendList []llm.Backend
+
+func (l *backendList) String() string {
+	names := make([]string, len(*l))
+	for i, b := range *l {
+		names[i] = b.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Set parses a backend given as provider/model@url. Its API key is read from the
+// environment variable named after the provider, e.g. GROQ_API_KEY for groq.
+func (l *backendList) Set(value string) error {
+	b, err := parseBackend(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, b)
+	return nil
+}
+
+// parseBackend parses a provider/model@url backend specification
+func parseBackend(value string) (llm.Backend, error) {
+	b, err := config.ParseBackend(value)
+	if err != nil {
+		return llm.Backend{}, err
+	}
+	return llm.Backend{
+		Provider: b.Provider,
+		Model:    b.Model,
+		BaseURL:  b.URL,
+		APIKey:   os.Getenv(apiKeyVariable(b.Provider)),
+	}, nil
+}
+
+// apiKeyVariable returns the environment variable holding the API key of provider
+func apiKeyVariable(provider string) string {
+	return strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_API_KEY"
+}
+
+// newLLMClient creates the client calling backends, or OpenAI's o1-mini when there are
+// none, with limit applied to every provider and the per-model limits of extra on top
+func newLLMClient(backends []llm.Backend, limit llm.RateLimit, extra ...llm.ClientOption) (*llm.Client, error) {
+	clientOpts := []llm.ClientOption{
+		llm.WithClientLogger(logger.DefaultLogger.Logger),
+		llm.WithRateLimit("openai", "", limit),
+	}
+	if len(backends) > 0 {
+		clientOpts = append(clientOpts, llm.WithBackends(backends...))
+		for _, b := range backends {
+			clientOpts = append(clientOpts, llm.WithRateLimit(b.Provider, "", limit))
+		}
+	}
+	clientOpts = append(clientOpts, extra...)
+	return llm.NewClient(http.DefaultClient, clientOpts...)
+}
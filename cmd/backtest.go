@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"nostradamus/internal/backtest"
+	"nostradamus/internal/config"
+	"nostradamus/internal/eval"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+)
+
+// runBacktest runs the pipeline on dated historical events and checks the predictions
+// against local price files
+func runBacktest(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("backtest", flag.ExitOnError)
+	eventsPath := flags.String("events", "", "JSON file of events with id, date and event, defaults to the bundled evaluation dataset")
+	eventIDs := flags.String("ids", "", "comma-separated ids of the events to run, defaults to all")
+	pricesDir := flags.String("prices", "", "directory of SYMBOL.csv daily OHLC files for the sector ETFs and tickers to check")
+	minMove := flags.Float64("min-move", 0, "return a bullish prediction must exceed, or a bearish one fall below the negative of")
+	volatileMove := flags.Float64("volatile-move", 0.05, "move from the starting close a volatile prediction must reach")
+	outPath := flags.String("out", "", "write the JSON report to this file")
+	rounds := flags.Int("rounds", 0, "number of predictor/critic refinement rounds after the first critique")
+	samples := flags.Int("samples", 1, "number of prediction sets to sample and cluster before the critique")
+	profile := flags.String("profile", "", "config file profile to use, defaults to NOSTRADAMUS_PROFILE or the file's default_profile")
+	flags.Parse(args)
+
+	if *pricesDir == "" {
+		return errors.New("no price directory provided, usage: go run main.go backtest -prices <dir> [-events <file.json>]")
+	}
+	events, err := loadBacktestEvents(*eventsPath)
+	if err != nil {
+		return fmt.Errorf("loading events: %w", err)
+	}
+	if *eventIDs != "" {
+		if events, err = selectEvents(events, strings.Split(*eventIDs, ","), func(e backtest.Event) string { return e.ID }); err != nil {
+			return err
+		}
+	}
+
+	cfg, err := config.Load(*profile)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	config.MaxAttempts = cfg.MaxAttempts
+	config.RetryDelay = time.Duration(cfg.RetryDelay)
+	prompts, err := loadPrompts(cfg.PromptsDir)
+	if err != nil {
+		return fmt.Errorf("loading prompts: %w", err)
+	}
+	llmClient, err := newLLMClient(profileBackends(cfg.Backends), llm.RateLimit{}, profileRateLimits(cfg.Backends, llm.RateLimit{})...)
+	if err != nil {
+		return fmt.Errorf("creating LLM client: %w", err)
+	}
+
+	report, err := backtestEvents(llmClient, events, backtest.NewPrices(*pricesDir),
+		backtest.Options{MinMove: *minMove, VolatileMove: *volatileMove},
+		llm.WithDebateRounds(*rounds),
+		llm.WithSamples(*samples),
+		llm.WithPrompts(prompts),
+	)
+	if err != nil {
+		return err
+	}
+	if err := backtest.WriteText(stdout, report); err != nil {
+		return err
+	}
+	if *outPath == "" {
+		return nil
+	}
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*outPath, append(encoded, '\n'), 0o644)
+}
+
+// backtestEvents runs the pipeline on each event as of the event's date, and resolves
+// every prediction against prices from that date. Failed runs are
+// recorded in the report and do not stop the backtest.
+func backtestEvents(client *llm.Client, events []backtest.Event, prices *backtest.Prices, o backtest.Options, opts ...llm.Option) (backtest.Report, error) {
+	var results []backtest.Result
+	failed := make(map[string]string)
+	for _, e := range events {
+		asOf, _ := time.Parse("2006-01-02", e.Date)
+		logger.Info("Backtesting event", "event_id", e.ID, "date", e.Date)
+		critiqued, err := llm.GenerateCritiquedResponse(e.Event, client, append(opts[:len(opts):len(opts)], llm.WithAsOf(asOf))...)
+		if err != nil {
+			logger.Error("Backtest run failed", "event_id", e.ID, "error", err)
+			failed[e.ID] = err.Error()
+			continue
+		}
+		for _, p := range critiqued.Predictions {
+			result, err := backtest.Resolve(e.ID, asOf, p, prices, o)
+			if err != nil {
+				return backtest.Report{}, fmt.Errorf("event %s: %w", e.ID, err)
+			}
+			results = append(results, result)
+		}
+	}
+	return backtest.NewReport(results, failed), nil
+}
+
+// loadBacktestEvents reads the events at path, or those of the bundled evaluation
+// dataset when no path is given
+func loadBacktestEvents(path string) ([]backtest.Event, error) {
+	if path == "" {
+		dataset, err := eval.Dataset()
+		if err != nil {
+			return nil, err
+		}
+		events := make([]backtest.Event, len(dataset))
+		for i, e := range dataset {
+			events[i] = backtest.Event{ID: e.ID, Date: e.Date, Event: e.Event}
+		}
+		return events, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return backtest.LoadEvents(f)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"nostradamus/internal/logger"
+	"nostradamus/internal/output"
+)
+
+// runCalendar exports saved critiqued predictions as an iCalendar file
+func runCalendar(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("calendar", flag.ExitOnError)
+	predictionsPath := flags.String("predictions", "-", "critiqued predictions JSON file, - for stdin")
+	date := flags.String("date", "", "date the predictions were made on, as YYYY-MM-DD, defaults to their as_of date or today")
+	flags.Parse(args)
+
+	critiqued, err := readCritiqued(*predictionsPath, stdin)
+	if err != nil {
+		return fmt.Errorf("reading predictions %s: %w", *predictionsPath, err)
+	}
+	start, err := critiqued.AsOfDate(time.Now())
+	if *date != "" {
+		start, err = time.ParseInLocation("2006-01-02", *date, time.Local)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	skipped, err := output.RenderICS(stdout, critiqued, start)
+	for _, i := range skipped {
+		logger.Error("Skipped prediction with an unrecognised timeframe in calendar export", "prediction", i, "timeframe", critiqued.Predictions[i].Timeframe)
+	}
+	return err
+}
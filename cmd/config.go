@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+	"nostradamus/internal/models"
+)
+
+// runConfig runs the config subcommands; "show" prints the resolved settings as JSON
+func runConfig(args []string, stdout io.Writer) error {
+	if len(args) < 1 || args[0] != "show" {
+		return fmt.Errorf("usage: go run main.go config show [-profile name]")
+	}
+	flags := flag.NewFlagSet("config show", flag.ExitOnError)
+	profile := flags.String("profile", "", "config file profile to resolve, defaults to NOSTRADAMUS_PROFILE or the file's default_profile")
+	flags.Parse(args[1:])
+
+	cfg, err := config.Load(*profile)
+	if err != nil {
+		return err
+	}
+	for i := range cfg.Sinks {
+		if cfg.Sinks[i].URL != "" {
+			cfg.Sinks[i].URL = maskURL(cfg.Sinks[i].URL)
+		}
+	}
+	encoded, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, string(encoded))
+	return err
+}
+
+// setFlags returns the names of the flags given on the command line
+func setFlags(flags *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// profileBackends returns the backends of a profile with their API keys read from the
+// environment. The keys are masked in logs, including those read from variables not named
+// *_API_KEY.
+func profileBackends(backends []config.Backend) []llm.Backend {
+	var resolved []llm.Backend
+	for _, b := range backends {
+		keyVariable := b.APIKeyEnv
+		if keyVariable == "" {
+			keyVariable = apiKeyVariable(b.Provider)
+		}
+		backend := llm.Backend{
+			Provider: b.Provider,
+			Model:    b.Model,
+			BaseURL:  b.URL,
+			APIKey:   os.Getenv(keyVariable),
+		}
+		if backend.APIKey != "" {
+			logger.DefaultLogger.AddSecrets(backend.APIKey)
+		}
+		if b.KnowledgeCutoff != nil {
+			backend.KnowledgeCutoff = time.Time(*b.KnowledgeCutoff)
+		}
+		resolved = append(resolved, backend)
+	}
+	return resolved
+}
+
+// profileRateLimits returns the client options applying the rate limits of backends to
+// their models. The non-zero fields of flags were given on the command line, so they
+// take precedence over the profile's.
+func profileRateLimits(backends []config.Backend, flags llm.RateLimit) []llm.ClientOption {
+	var opts []llm.ClientOption
+	for _, b := range backends {
+		if b.RateLimit == nil {
+			continue
+		}
+		limit := llm.RateLimit{
+			RequestsPerMinute: b.RateLimit.RequestsPerMinute,
+			TokensPerMinute:   b.RateLimit.TokensPerMinute,
+			MaxConcurrent:     b.RateLimit.MaxConcurrent,
+		}
+		if flags.RequestsPerMinute > 0 {
+			limit.RequestsPerMinute = flags.RequestsPerMinute
+		}
+		if flags.TokensPerMinute > 0 {
+			limit.TokensPerMinute = flags.TokensPerMinute
+		}
+		if flags.MaxConcurrent > 0 {
+			limit.MaxConcurrent = flags.MaxConcurrent
+		}
+		opts = append(opts, llm.WithRateLimit(b.Provider, b.Model, limit))
+	}
+	return opts
+}
+
+// loadPrompts loads the prompt templates in dir, returning nil when no dir is given
+func loadPrompts(dir string) (*llm.Prompts, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	return llm.LoadPrompts(dir)
+}
+
+// saveResult writes result as JSON to a new file in dir named after the current time and
+// returns its path
+func saveResult(dir string, result *models.CritiquedResponse) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, time.Now().UTC().Format("20060102T150405Z")+"-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/eval"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+)
+
+// runEval runs the pipeline over a dataset of historical events, prints the scores and,
+// given a baseline report, fails when a summary metric regressed
+func runEval(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("eval", flag.ExitOnError)
+	datasetPath := flags.String("dataset", "", "JSON file of historical events, defaults to the bundled dataset")
+	eventIDs := flags.String("events", "", "comma-separated ids of the events to run, defaults to all")
+	judge := flags.Bool("judge", false, "also have the LLM judge each run against what happened")
+	outPath := flags.String("out", "", "write the JSON report to this file")
+	baselinePath := flags.String("baseline", "", "JSON report to compare against; regressions fail the command")
+	regressionTolerance := flags.Float64("max-regression", 0.02, "largest drop of a summary metric tolerated against the baseline")
+	rounds := flags.Int("rounds", 0, "number of predictor/critic refinement rounds after the first critique")
+	samples := flags.Int("samples", 1, "number of prediction sets to sample and cluster before the critique")
+	profile := flags.String("profile", "", "config file profile to use, defaults to NOSTRADAMUS_PROFILE or the file's default_profile")
+	promptsDir := flags.String("prompts-dir", "", "directory of prompt templates to evaluate, overrides the profile's")
+	flags.Parse(args)
+
+	events, err := loadEvents(*datasetPath)
+	if err != nil {
+		return fmt.Errorf("loading dataset: %w", err)
+	}
+	var ids []string
+	if *eventIDs != "" {
+		ids = strings.Split(*eventIDs, ",")
+	}
+	if events, err = selectEvents(events, ids, func(e eval.Event) string { return e.ID }); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(*profile)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if setFlags(flags)["prompts-dir"] {
+		cfg.PromptsDir = *promptsDir
+		cfg.SetFlag("prompts_dir")
+	}
+	config.MaxAttempts = cfg.MaxAttempts
+	config.RetryDelay = time.Duration(cfg.RetryDelay)
+	prompts, err := loadPrompts(cfg.PromptsDir)
+	if err != nil {
+		return fmt.Errorf("loading prompts: %w", err)
+	}
+	llmClient, err := newLLMClient(profileBackends(cfg.Backends), llm.RateLimit{}, profileRateLimits(cfg.Backends, llm.RateLimit{})...)
+	if err != nil {
+		return fmt.Errorf("creating LLM client: %w", err)
+	}
+
+	report := eval.NewReport(evaluate(llmClient, events, *judge,
+		llm.WithDebateRounds(*rounds),
+		llm.WithSamples(*samples),
+		llm.WithPrompts(prompts),
+	))
+	report.Profile = cfg.Profile
+	if err := eval.WriteText(stdout, report); err != nil {
+		return err
+	}
+	if *outPath != "" {
+		if err := writeReport(*outPath, report); err != nil {
+			return fmt.Errorf("writing report %s: %w", *outPath, err)
+		}
+	}
+	if *baselinePath == "" {
+		return nil
+	}
+	return compareWithBaseline(stdout, *baselinePath, report, *regressionTolerance)
+}
+
+// evaluate runs the pipeline on each event as of the event's date, so the model cannot draw
+// on what came next, and scores the result, asking the LLM judge too when judge is set.
+// Failed runs are kept in the results with the worst scores.
+func evaluate(client *llm.Client, events []eval.Event, judge bool, opts ...llm.Option) []eval.EventResult {
+	results := make([]eval.EventResult, 0, len(events))
+	for _, e := range events {
+		logger.Info("Evaluating event", "event_id", e.ID)
+		result := eval.EventResult{ID: e.ID}
+		asOf, _ := time.Parse("2006-01-02", e.Date)
+		critiqued, err := llm.GenerateCritiquedResponse(e.Event, client, append(opts[:len(opts):len(opts)], llm.WithAsOf(asOf))...)
+		if err != nil {
+			logger.Error("Evaluation run failed", "event_id", e.ID, "error", err)
+			result.Error = err.Error()
+			result.Scores = eval.Score(e, nil)
+			results = append(results, result)
+			continue
+		}
+		result.Backend = critiqued.Backend
+		result.Predictions = len(critiqued.Predictions)
+		result.Scores = eval.Score(e, critiqued)
+		if judge {
+			judgement, err := llm.Judge(client, e.Event, e.Summary, critiqued)
+			if err != nil {
+				logger.Error("Judging evaluation run failed", "event_id", e.ID, "error", err)
+			} else {
+				result.Scores = result.Scores.WithJudge(judgement.Score)
+				result.JudgeRationale = judgement.Rationale
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// compareWithBaseline prints how report compares with the report at baselinePath and
+// returns an error when a metric regressed by more than tolerance
+func compareWithBaseline(stdout io.Writer, baselinePath string, report eval.Report, tolerance float64) error {
+	f, err := os.Open(baselinePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	baseline, err := eval.ReadReport(f)
+	if err != nil {
+		return fmt.Errorf("reading baseline %s: %w", baselinePath, err)
+	}
+	deltas, err := eval.Compare(baseline, report, tolerance)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout)
+	if err := eval.WriteComparison(stdout, deltas); err != nil {
+		return err
+	}
+	var regressed []string
+	for _, d := range deltas {
+		if d.Regressed {
+			regressed = append(regressed, d.Metric)
+		}
+	}
+	if len(regressed) > 0 {
+		return fmt.Errorf("regressed against %s: %s", baselinePath, strings.Join(regressed, ", "))
+	}
+	return nil
+}
+
+// selectEvents returns the events with the given ids, in file order; no ids selects
+// them all
+func selectEvents[E any](events []E, ids []string, id func(E) string) ([]E, error) {
+	if len(ids) == 0 {
+		return events, nil
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var selected []E
+	for _, e := range events {
+		if wanted[id(e)] {
+			selected = append(selected, e)
+			delete(wanted, id(e))
+		}
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("unknown event %q", id)
+	}
+	return selected, nil
+}
+
+// loadEvents reads the dataset at path, or the bundled dataset when no path is given
+func loadEvents(path string) ([]eval.Event, error) {
+	if path == "" {
+		return eval.Dataset()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return eval.LoadDataset(f)
+}
+
+// writeReport writes report as indented JSON to path
+func writeReport(path string, report eval.Report) error {
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(encoded, '\n'), 0o644)
+}
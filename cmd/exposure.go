@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/portfolio"
+)
+
+// runExposure maps critiqued predictions onto a portfolio and prints the exposure report
+func runExposure(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("exposure", flag.ExitOnError)
+	portfolioPath := flags.String("portfolio", "", "CSV file of holdings with ticker, weight and sector columns")
+	predictionsPath := flags.String("predictions", "-", "critiqued predictions JSON file, - for stdin")
+	flags.Parse(args)
+
+	if *portfolioPath == "" {
+		return errors.New("no portfolio provided, usage: go run main.go exposure -portfolio <file.csv> [-predictions <file.json>]")
+	}
+	f, err := os.Open(*portfolioPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	holdings, err := portfolio.Load(f)
+	if err != nil {
+		return fmt.Errorf("loading portfolio %s: %w", *portfolioPath, err)
+	}
+
+	critiqued, err := readCritiqued(*predictionsPath, stdin)
+	if err != nil {
+		return fmt.Errorf("reading predictions %s: %w", *predictionsPath, err)
+	}
+
+	report, err := json.Marshal(portfolio.Analyse(holdings, critiqued))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, string(report))
+	return nil
+}
+
+// readCritiqued decodes critiqued predictions from path, or from stdin when path is "-"
+func readCritiqued(path string, stdin io.Reader) (models.CritiquedResponse, error) {
+	var critiqued models.CritiquedResponse
+	r := stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return critiqued, err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := json.NewDecoder(r).Decode(&critiqued); err != nil {
+		return critiqued, err
+	}
+	return critiqued, critiqued.Validate()
+}
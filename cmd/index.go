@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"nostradamus/internal/retrieval"
+)
+
+// runIndex builds the BM25 index of a directory of research notes used with -index
+func runIndex(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("index", flag.ExitOnError)
+	dir := flags.String("dir", "", "directory of .txt and .md documents to index, searched recursively")
+	outPath := flags.String("out", "index.json", "file the index is written to")
+	maxWords := flags.Int("passage-words", 200, "approximate number of words per passage")
+	flags.Parse(args)
+
+	if *dir == "" {
+		return errors.New("no directory provided, usage: go run main.go index -dir <notes> [-out index.json]")
+	}
+	idx, err := retrieval.Build(*dir, *maxWords)
+	if err != nil {
+		return fmt.Errorf("indexing %s: %w", *dir, err)
+	}
+	if err := idx.Save(*outPath); err != nil {
+		return fmt.Errorf("saving index to %s: %w", *outPath, err)
+	}
+	_, err = fmt.Fprintf(stdout, "Indexed %d passages from %s into %s\n", len(idx.Passages), *dir, *outPath)
+	return err
+}
+
+// loadIndex reads the index at path, returning nil when no path is given
+func loadIndex(path string) (*retrieval.Index, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return retrieval.Load(path)
+}
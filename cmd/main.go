@@ -1,33 +1,312 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
-	"nostradamus/internal/logger"
+	"nostradamus/internal/analogs"
+	"nostradamus/internal/calibration"
+	"nostradamus/internal/config"
 	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+	"nostradamus/internal/metrics"
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+	"nostradamus/internal/sinks"
+	"nostradamus/internal/tracing"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		logger.Error("No input provided", "usage", "go run main.go <input>")
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	var err error
+	switch command {
+	case "exposure":
+		err = runExposure(os.Args[2:], os.Stdin, os.Stdout)
+	case "calendar":
+		err = runCalendar(os.Args[2:], os.Stdin, os.Stdout)
+	case "config":
+		err = runConfig(os.Args[2:], os.Stdout)
+	case "eval":
+		err = runEval(os.Args[2:], os.Stdout)
+	case "backtest":
+		err = runBacktest(os.Args[2:], os.Stdout)
+	case "index":
+		err = runIndex(os.Args[2:], os.Stdout)
+	case "watch":
+		err = runWatch(os.Args[2:], os.Stdout)
+	default:
+		err = runPredict(os.Args[1:], os.Stdout)
+	}
+	if err != nil {
+		logger.Error("Command failed", "error", err)
 		os.Exit(1)
 	}
-	input := strings.Join(os.Args[1:], " ")
+}
+
+// runPredict generates critiqued predictions for the event given in args and prints them
+func runPredict(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("nostradamus", flag.ExitOnError)
+	rounds := flags.Int("rounds", 0, "number of predictor/critic refinement rounds after the first critique")
+	tolerance := flags.Float64("tolerance", 0.05, "confidence shift at which refinement rounds stop early")
+	samples := flags.Int("samples", 1, "number of prediction sets to sample and cluster before the critique")
+	sampleTemperature := flags.Float64("sample-temperature", 1, "temperature used when sampling several prediction sets")
+	asOfDate := flags.String("as-of", "", "date the predictions are made on, as YYYY-MM-DD, defaults to today")
+	tickersPath := flags.String("tickers", "", "file of ticker symbols predictions may reference")
+	indexPath := flags.String("index", "", "BM25 index built by the index command to retrieve research passages from")
+	topK := flags.Int("top-k", 5, "number of research passages retrieved from -index into the prompts")
+	analogCount := flags.Int("analogs", 0, "number of similar past events, with their observed sector reactions, shown to the critic; 0, the default, disables them")
+	analogsPath := flags.String("analogs-file", "", "JSON file of past events to draw analogs from, defaults to the bundled dataset")
+	coherence := flags.String("coherence", "", "check for mutually exclusive or contradictory predictions: flag reports them, normalise also scales the confidences of inconsistent groups to sum to 1")
+	dedupe := flags.Bool("dedupe", false, "merge near-duplicate predictions before the critique, keeping the earliest timeframe")
+	dedupeThreshold := flags.Float64("dedupe-threshold", 0.5, "similarity from 0 to 1 at which -dedupe merges two predictions")
+	embeddings := flags.String("embeddings", "", "embedding model as provider/model@url, e.g. openai/text-embedding-3-small@https://api.openai.com/v1/embeddings, averaged with lexical similarity by -dedupe")
+	persona := flags.String("persona", "", "name of the critic persona, such as the one written into custom critique templates, recorded on results and used to pick the calibration map")
+	calibratePath := flags.String("calibrate", "", "JSON file of resolved past predictions to calibrate confidences against; the critic's confidence is kept as raw_confidence")
+	calibrationMethod := flags.String("calibration-method", calibration.MethodPlatt, "calibration map fitted on -calibrate: "+strings.Join(calibration.Methods, " or "))
+	calibrationMin := flags.Int("calibration-min", 20, "resolved predictions needed to fit a separate map for a model, a persona or both")
+	noNotify := flags.Bool("no-notify", false, "skip delivering the result to the profile's sinks")
+	format := flags.String("format", "json", "output format: "+strings.Join(output.Formats, ", ")+", overrides the profile's")
+	traceFile := flags.String("trace-file", "", "append the run's trace as OTLP/JSON to this file")
+	metricsAddr := flags.String("metrics-addr", "", "serve Prometheus metrics on /metrics at this address, e.g. :9090, and keep serving after a successful run until interrupted")
+	rpm := flags.Int("rpm", 0, "maximum LLM requests per minute, 0 for no limit")
+	tpm := flags.Int("tpm", 0, "maximum LLM tokens per minute, 0 for no limit")
+	maxConcurrent := flags.Int("max-concurrent", 0, "maximum LLM requests in flight at once, 0 for no limit")
+	var backends backendList
+	flags.Var(&backends, "backend", "LLM backend as provider/model@url, repeat in priority order to fail over; the API key is read from PROVIDER_API_KEY")
+	traceEndpoint := flags.String("trace-endpoint", "", "post the run's trace as OTLP/JSON to this collector URL, e.g. http://localhost:4318/v1/traces")
+	traceTimeout := flags.Duration("trace-timeout", 10*time.Second, "time allowed to post the trace to the collector")
+	profile := flags.String("profile", "", "config file profile to use, defaults to NOSTRADAMUS_PROFILE or the file's default_profile")
+	maxAttempts := flags.Int("max-attempts", 10, "attempts per stage before the run fails, overrides the profile's")
+	retryDelay := flags.Duration("retry-delay", time.Second, "wait between attempts, overrides the profile's")
+	storagePath := flags.String("storage-path", "", "directory each result is also saved to, overrides the profile's")
+	promptsDir := flags.String("prompts-dir", "", "directory of prediction.tmpl, critique.tmpl and revision.tmpl prompt templates, overrides the profile's")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		return fmt.Errorf("no input provided, usage: go run main.go [flags] <input>")
+	}
+	input := strings.Join(flags.Args(), " ")
 	logger.Info("Received input", "input", input)
 
-	llmClient, err := llm.NewClient(http.DefaultClient)
+	asOf := time.Now()
+	if *asOfDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *asOfDate, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid as-of date %q: %w", *asOfDate, err)
+		}
+		asOf = parsed
+	}
+
+	cfg, err := config.Load(*profile)
 	if err != nil {
-		logger.Error("Error creating LLM client", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("loading config: %w", err)
+	}
+	set := setFlags(flags)
+	if set["format"] {
+		cfg.Format = *format
+		cfg.SetFlag("format")
+	}
+	if set["max-attempts"] {
+		cfg.MaxAttempts = *maxAttempts
+		cfg.SetFlag("max_attempts")
+	}
+	if set["retry-delay"] {
+		cfg.RetryDelay = config.Duration(*retryDelay)
+		cfg.SetFlag("retry_delay")
+	}
+	if set["storage-path"] {
+		cfg.StoragePath = *storagePath
+		cfg.SetFlag("storage_path")
+	}
+	if set["prompts-dir"] {
+		cfg.PromptsDir = *promptsDir
+		cfg.SetFlag("prompts_dir")
 	}
-	result, err := llm.GenerateCritiquedPredictions(input, llmClient)
+	config.MaxAttempts = cfg.MaxAttempts
+	config.RetryDelay = time.Duration(cfg.RetryDelay)
+	if len(backends) > 0 {
+		cfg.SetFlag("backends")
+	} else {
+		backends = profileBackends(cfg.Backends)
+	}
+	logger.Info("Resolved settings", "profile", cfg.Profile, "sources", cfg.Sources)
+
+	prompts, err := loadPrompts(cfg.PromptsDir)
 	if err != nil {
-		logger.Error("Error generating critiqued predictions", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("loading prompts: %w", err)
+	}
+
+	tickers, err := loadTickers(*tickersPath)
+	if err != nil {
+		return fmt.Errorf("loading ticker list %s: %w", *tickersPath, err)
+	}
+
+	if *coherence != "" && *coherence != models.CoherenceFlag && *coherence != models.CoherenceNormalise {
+		return fmt.Errorf("invalid coherence mode %q, expected %s or %s", *coherence, models.CoherenceFlag, models.CoherenceNormalise)
+	}
+
+	opts := []llm.Option{
+		llm.WithDebateRounds(*rounds),
+		llm.WithDebateTolerance(*tolerance),
+		llm.WithSamples(*samples),
+		llm.WithSampleTemperature(*sampleTemperature),
+		llm.WithTickers(tickers),
+		llm.WithAsOf(asOf),
+		llm.WithPrompts(prompts),
+		llm.WithCoherence(*coherence),
+		llm.WithPersona(*persona),
+	}
+	index, err := loadIndex(*indexPath)
+	if err != nil {
+		return fmt.Errorf("loading index %s: %w", *indexPath, err)
+	}
+	if index != nil {
+		opts = append(opts, llm.WithRetriever(index, *topK))
+	}
+	if *analogCount > 0 {
+		dataset, err := loadAnalogs(*analogsPath)
+		if err != nil {
+			return fmt.Errorf("loading analogs: %w", err)
+		}
+		opts = append(opts, llm.WithAnalogs(dataset, *analogCount))
+	}
+
+	if *dedupe {
+		opts = append(opts, llm.WithDedupe(*dedupeThreshold))
+		if *embeddings != "" {
+			b, err := parseBackend(*embeddings)
+			if err != nil {
+				return fmt.Errorf("invalid embeddings: %w", err)
+			}
+			opts = append(opts, llm.WithEmbedder(llm.NewEmbedder(http.DefaultClient, b)))
+		}
+	}
+
+	var targets []sinks.Sink
+	if !*noNotify {
+		targets, err = profileSinks(cfg.Sinks, http.DefaultClient)
+		if err != nil {
+			return fmt.Errorf("creating sinks: %w", err)
+		}
+	}
+
+	calibrator, err := loadCalibrator(*calibratePath, *calibrationMethod, *calibrationMin)
+	if err != nil {
+		return fmt.Errorf("calibrating against %s: %w", *calibratePath, err)
+	}
+	if calibrator != nil {
+		opts = append(opts, llm.WithCalibrator(calibrator))
+	}
+
+	var llmMetrics *llm.Metrics
+	var metricsServer *http.Server
+	if *metricsAddr != "" {
+		registry := metrics.NewRegistry()
+		llmMetrics = llm.NewMetrics(registry)
+		server, _, err := serveMetrics(*metricsAddr, registry)
+		if err != nil {
+			return fmt.Errorf("serving metrics on %s: %w", *metricsAddr, err)
+		}
+		// A failed run stops serving at once; a successful one serves until interrupted
+		defer server.Close()
+		metricsServer = server
+	}
+
+	limit := llm.RateLimit{RequestsPerMinute: *rpm, TokensPerMinute: *tpm, MaxConcurrent: *maxConcurrent}
+	llmClient, err := newLLMClient(backends, limit, profileRateLimits(cfg.Backends, limit)...)
+	if err != nil {
+		return fmt.Errorf("creating LLM client: %w", err)
+	}
+	opts = append(opts, llm.WithTracer(newTracer(*traceFile, *traceEndpoint, *traceTimeout)), llm.WithMetrics(llmMetrics))
+	result, err := llm.GenerateCritiquedResponse(input, llmClient, opts...)
+	if err != nil {
+		return fmt.Errorf("generating critiqued predictions: %w", err)
 	}
 	logger.Info("Final valid critiqued predictions", "result", result)
-	fmt.Println(result)
+	if cfg.StoragePath != "" {
+		path, err := saveResult(cfg.StoragePath, result)
+		if err != nil {
+			return fmt.Errorf("saving result to %s: %w", cfg.StoragePath, err)
+		}
+		logger.Info("Saved result", "path", path)
+	}
+	if err := output.Render(stdout, cfg.Format, *result); err != nil {
+		return err
+	}
+	if err := notify(targets, *result); err != nil {
+		return fmt.Errorf("delivering result: %w", err)
+	}
+	if metricsServer != nil {
+		if err := waitForInterrupt(metricsServer); err != nil {
+			return fmt.Errorf("shutting down metrics server: %w", err)
+		}
+	}
+	return nil
+}
+
+// newTracer returns a tracer exporting to the given file or collector endpoint, or nil
+// when neither is set. Posts to the collector give up after timeout, so a stalled
+// collector cannot hang the end of the run.
+func newTracer(file, endpoint string, timeout time.Duration) *tracing.Tracer {
+	switch {
+	case file != "":
+		return tracing.NewTracer(tracing.NewFileExporter(file))
+	case endpoint != "":
+		return tracing.NewTracer(tracing.NewHTTPExporter(endpoint, &http.Client{Timeout: timeout}))
+	default:
+		return nil
+	}
+}
+
+// loadAnalogs reads the past events at path, or the bundled ones when no path is given
+func loadAnalogs(path string) (*analogs.Dataset, error) {
+	if path == "" {
+		return analogs.Bundled()
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return analogs.Load(f)
+}
+
+// loadCalibrator fits the calibration maps of method on the resolved predictions at path,
+// returning nil when no path is given
+func loadCalibrator(path, method string, minRecords int) (*calibration.Calibrator, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := calibration.LoadRecords(f)
+	if err != nil {
+		return nil, err
+	}
+	return calibration.Fit(records, method, minRecords)
+}
+
+// loadTickers reads the ticker list at path, returning an empty set when no path is given
+func loadTickers(path string) (models.TickerSet, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return models.ParseTickers(f)
 }
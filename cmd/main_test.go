@@ -6,14 +6,28 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"nostradamus/internal/backtest"
+	"nostradamus/internal/calibration"
 	"nostradamus/internal/config"
+	"nostradamus/internal/eval"
+	"nostradamus/internal/feeds"
 	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/logger"
+	"nostradamus/internal/metrics"
 	"nostradamus/internal/models"
+	"nostradamus/internal/portfolio"
+	"nostradamus/internal/retrieval"
+	"nostradamus/internal/sinks"
 )
 
 func generatePredictions(input string, httpClient *http.Client) (string, error) {
@@ -506,3 +520,655 @@ config.RetryDelay = 1 * time.Millisecond
 		t.Errorf("Expected at least 10 attempts for critique call, got: %d", callCount)
 	}
 }
+
+// Tests for the portfolio exposure report
+
+func TestExposureReport(t *testing.T) {
+	portfolioPath := t.TempDir() + "/portfolio.csv"
+	if err := os.WriteFile(portfolioPath, []byte("ticker,weight,sector\nXOM,0.3,Energy\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write portfolio: %v", err)
+	}
+	predictions := `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Oil spikes", "impact": "Energy rallies", "sectors": ["Energy"], "direction": "bullish", "confidence": 0.8, "critique": "Likely"}]}`
+
+	var out bytes.Buffer
+	err := runExposure([]string{"-portfolio", portfolioPath}, strings.NewReader(predictions), &out)
+	if err != nil {
+		t.Fatalf("Expected exposure report, got error: %v", err)
+	}
+	var report portfolio.Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to parse exposure report: %v", err)
+	}
+	if len(report.Positions) != 1 || len(report.Positions[0].Exposures) != 1 {
+		t.Errorf("Expected XOM exposed to the oil prediction, got: %+v", report)
+	}
+	if err := runExposure(nil, strings.NewReader(predictions), &out); err == nil {
+		t.Error("Expected error without a portfolio, got nil")
+	}
+}
+
+// Tests for the iCalendar export
+
+func TestCalendarExport(t *testing.T) {
+	predictions := `{"original_prompt": "test event", "predictions": [{"timeframe": "2 weeks", "description": "Event A", "impact": "Energy rallies", "confidence": 0.9, "critique": "Likely"}]}`
+	var out bytes.Buffer
+	if err := runCalendar([]string{"-date", "2025-01-31"}, strings.NewReader(predictions), &out); err != nil {
+		t.Fatalf("Expected calendar export, got error: %v", err)
+	}
+	if !strings.Contains(out.String(), "DTSTART;VALUE=DATE:20250214\r\n") {
+		t.Errorf("Expected the timeframe counted from the -date flag, got: %s", out.String())
+	}
+	if err := runCalendar([]string{"-date", "31/01/2025"}, strings.NewReader(predictions), &out); err == nil {
+		t.Error("Expected error for an invalid date, got nil")
+	}
+}
+
+func TestCalendarCountsFromAsOf(t *testing.T) {
+	predictions := `{"original_prompt": "test event", "as_of": "2023-03-10", "predictions": [{"timeframe": "6 months", "description": "Event A", "impact": "Market volatility", "confidence": 0.5, "critique": "Plausible"}]}`
+	var out bytes.Buffer
+	if err := runCalendar(nil, strings.NewReader(predictions), &out); err != nil {
+		t.Fatalf("Expected calendar export, got error: %v", err)
+	}
+	if !strings.Contains(out.String(), "DTSTART;VALUE=DATE:20230910\r\n") {
+		t.Errorf("Expected the timeframe counted from the as-of date, got: %s", out.String())
+	}
+}
+
+// Tests for tracing
+
+func TestTraceExportTimeout(t *testing.T) {
+	release := make(chan struct{})
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer collector.Close()
+	defer close(release)
+
+	start := time.Now()
+	err := newTracer("", collector.URL, 50*time.Millisecond).Start("run").End()
+	if err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("Expected the export to a stalled collector to time out, got: %v after %s", err, time.Since(start))
+	}
+}
+
+// Tests for Prometheus metrics
+
+func TestServeMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	registry.NewCounter("nostradamus_runs_total", "Runs").Inc()
+	server, addr, err := serveMetrics("127.0.0.1:0", registry)
+	if err != nil {
+		t.Fatalf("Failed to serve metrics: %v", err)
+	}
+	defer server.Close()
+	resp, err := http.Get("http://" + addr.String() + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "nostradamus_runs_total 1\n") {
+		t.Errorf("Expected the registry served on /metrics, got:\n%s", body)
+	}
+}
+
+func TestFailedRunStopsServingMetrics(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir())
+	t.Setenv("NOSTRADAMUS_CONFIG", "")
+	t.Setenv("NOSTRADAMUS_PROFILE", "")
+	t.Setenv("NOSTRADAMUS_BACKENDS", "")
+	t.Setenv("OPENAI_API_KEY", "")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runPredict([]string{"-metrics-addr", "127.0.0.1:0", "x"}, io.Discard)
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY") {
+			t.Errorf("Expected the run to fail without an API key, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a failed run to return instead of serving metrics until interrupted")
+	}
+}
+
+// Tests for rate limiting
+
+func TestProfileRateLimits(t *testing.T) {
+	llmtest.Setup(t)
+	var inFlight llmtest.InFlight
+	client := llmtest.HTTPClient(inFlight.Handler(20 * time.Millisecond))
+	var file config.File
+	if err := json.Unmarshal([]byte(`{"profiles": {"prod": {"backends": [
+		{"provider": "groq", "model": "llama3", "url": "https://api.groq.com/openai/v1/chat/completions", "rate_limit": {"rpm": 600}},
+		{"provider": "openai", "model": "o1-mini", "url": "https://api.openai.com/v1/chat/completions", "rate_limit": {"max_concurrent": 1}}
+	]}}}`), &file); err != nil {
+		t.Fatal(err)
+	}
+	backends := file.Profiles["prod"].Backends
+	opts := profileRateLimits(backends, llm.RateLimit{})
+	if len(opts) != 2 {
+		t.Fatalf("Expected a limit per backend, got %d", len(opts))
+	}
+	llmClient, err := llm.NewClient(client, append([]llm.ClientOption{llm.WithRateLimit("openai", "", llm.RateLimit{MaxConcurrent: 4})}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := llmClient.CallLLM("hi"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if inFlight.Max() != 1 {
+		t.Errorf("Expected the o1-mini limit of the profile to override the provider's, got %d in flight", inFlight.Max())
+	}
+
+	// A limit given by flag overrides the profile's for every model
+	flagLimit := llm.RateLimit{MaxConcurrent: 3}
+	llmClient, err = llm.NewClient(client, append([]llm.ClientOption{llm.WithRateLimit("openai", "", flagLimit)}, profileRateLimits(backends, flagLimit)...)...)
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+	inFlight.Reset()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := llmClient.CallLLM("hi"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if inFlight.Max() != 3 {
+		t.Errorf("Expected the -max-concurrent flag to override the profile, got %d in flight", inFlight.Max())
+	}
+}
+
+// Tests for provider failover
+
+func TestParseBackend(t *testing.T) {
+	t.Setenv("GROQ_API_KEY", "groq-key")
+	b, err := parseBackend("groq/llama-3.1-70b@https://api.groq.com/openai/v1/chat/completions")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.Provider != "groq" || b.Model != "llama-3.1-70b" || b.BaseURL != "https://api.groq.com/openai/v1/chat/completions" || b.APIKey != "groq-key" {
+		t.Errorf("Unexpected backend: %+v", b)
+	}
+	for _, invalid := range []string{"groq", "groq/llama", "groq@https://x", "/m@https://x"} {
+		if _, err := parseBackend(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// Tests for config files and profiles
+
+func writeConfigFile(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	dir := home + "/nostradamus"
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := `{
+  "default_profile": "dev",
+  "profiles": {
+    "dev": {"format": "pretty", "retry": {"max_attempts": 3, "delay": "10ms"}},
+    "local-llm": {
+      "backends": [{"provider": "ollama", "model": "llama3", "url": "http://localhost:11434/v1/chat/completions", "api_key_env": "LOCAL_KEY"}],
+      "storage_path": "/tmp/nostradamus-runs",
+      "prompts_dir": "/tmp/prompts",
+      "sinks": [{"type": "slack", "url": "https://hooks.slack.com/services/T0/B0/sinktoken"}]
+    }
+  }
+}`
+	if err := os.WriteFile(dir+"/config.json", []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestProfileBackends(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", writeConfigFile(t))
+	t.Setenv("NOSTRADAMUS_CONFIG", "")
+	t.Setenv("NOSTRADAMUS_PROFILE", "")
+	t.Setenv("NOSTRADAMUS_BACKENDS", "")
+	t.Setenv("LOCAL_KEY", "local-secret")
+
+	cfg, err := config.Load("local-llm")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	backends := profileBackends(cfg.Backends)
+	if len(backends) != 1 || backends[0].Name() != "ollama/llama3" || backends[0].APIKey != "local-secret" || backends[0].BaseURL != "http://localhost:11434/v1/chat/completions" {
+		t.Errorf("Expected the profile's backend with the key from its api_key_env, got: %+v", backends)
+	}
+}
+
+func TestConfigShow(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", writeConfigFile(t))
+	t.Setenv("NOSTRADAMUS_CONFIG", "")
+	t.Setenv("NOSTRADAMUS_PROFILE", "")
+	t.Setenv("NOSTRADAMUS_FORMAT", "")
+	t.Setenv("OPENAI_API_KEY", "testkey")
+
+	var out bytes.Buffer
+	if err := runConfig([]string{"show", "-profile", "local-llm"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var shown map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &shown); err != nil {
+		t.Fatalf("Failed to parse config show output: %v\n%s", err, out.String())
+	}
+	if shown["profile"] != "local-llm" || shown["storage_path"] != "/tmp/nostradamus-runs" || shown["retry_delay"] != "1s" {
+		t.Errorf("Unexpected config show output: %s", out.String())
+	}
+	if strings.Contains(out.String(), "testkey") || strings.Contains(out.String(), "sinktoken") || !strings.Contains(out.String(), "https://hooks.slack.com/[REDACTED]") {
+		t.Errorf("Expected secrets left out of config show, got: %s", out.String())
+	}
+	if err := runConfig([]string{"edit"}, &out); err == nil {
+		t.Errorf("Expected an error for an unknown config subcommand")
+	}
+}
+
+func TestKnowledgeCutoffConfig(t *testing.T) {
+	cutoff := config.Date(time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC))
+	backends := profileBackends([]config.Backend{
+		{Provider: "groq", Model: "llama3", URL: "https://api.groq.com/openai/v1/chat/completions", KnowledgeCutoff: &cutoff},
+		{Provider: "ollama", Model: "llama3", URL: "http://localhost:11434/v1/chat/completions", KnowledgeCutoff: &config.Date{}},
+		{Provider: "openai", Model: "o1-mini", URL: "https://api.openai.com/v1/chat/completions"},
+	})
+	if !backends[0].KnowledgeCutoff.Equal(time.Time(cutoff)) || !backends[1].KnowledgeCutoff.IsZero() || !backends[2].KnowledgeCutoff.IsZero() {
+		t.Errorf("Expected the set cutoff passed on and the others left unset, got %+v", backends)
+	}
+}
+
+func TestSaveResult(t *testing.T) {
+	dir := t.TempDir() + "/runs"
+	result := &models.CritiquedResponse{OriginalPrompt: "test event", Predictions: []models.CritiquedPrediction{{Timeframe: "1 week", Description: "Event A", Impact: "Volatility", Confidence: 0.5, Critique: "Plausible"}}}
+	path, err := saveResult(dir, result)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	saved, err := readCritiqued(path, nil)
+	if err != nil {
+		t.Fatalf("Failed to read saved result: %v", err)
+	}
+	if saved.OriginalPrompt != "test event" || len(saved.Predictions) != 1 {
+		t.Errorf("Unexpected saved result: %+v", saved)
+	}
+}
+
+// Tests for the evaluation harness
+
+func TestSelectEvents(t *testing.T) {
+	events, err := eval.Dataset()
+	if err != nil {
+		t.Fatalf("Bundled dataset is invalid: %v", err)
+	}
+	selected, err := selectEvents(events, []string{"svb-collapse", "lehman-bankruptcy"}, func(e eval.Event) string { return e.ID })
+	if err != nil || len(selected) != 2 || selected[0].ID != "lehman-bankruptcy" {
+		t.Errorf("Expected both events in dataset order, got %v, %v", selected, err)
+	}
+	if _, err := selectEvents(events, []string{"moon-landing"}, func(e eval.Event) string { return e.ID }); err == nil {
+		t.Errorf("Expected an unknown event id to be rejected")
+	}
+}
+
+func TestEvaluateWithJudge(t *testing.T) {
+	llmClient := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "judging stock market predictions"):
+			if !strings.Contains(body, "regional bank shares plunged") {
+				t.Errorf("Expected the judge to see the observed outcome")
+			}
+			return http.StatusOK, `{"score": 0.9, "rationale": "Anticipated the bank selloff."}`
+		case !strings.Contains(body, "Today is 2023-03-10"):
+			t.Errorf("Expected the event date as the as-of date, got prompt: %s", body)
+		}
+		if llmtest.IsCritique(body) {
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses", "sectors": ["Financials"], "direction": "bearish", "confidence": 0.7, "critique": "Likely"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses", "sectors": ["Financials"], "direction": "bearish"}]}`
+	})
+	events, _ := eval.Dataset()
+	events, _ = selectEvents(events, []string{"svb-collapse"}, func(e eval.Event) string { return e.ID })
+	report := eval.NewReport(evaluate(llmClient, events, true))
+
+	if len(report.Events) != 1 || report.Events[0].Error != "" || report.Events[0].Backend != "openai/o1-mini" {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	scores := report.Summary
+	if scores.SectorRecall != 0.5 || scores.DirectionAccuracy != 1 || scores.Judge == nil || *scores.Judge != 0.9 {
+		t.Errorf("Unexpected summary: %+v", scores)
+	}
+	if report.Events[0].JudgeRationale != "Anticipated the bank selloff." {
+		t.Errorf("Expected the judge rationale kept, got %q", report.Events[0].JudgeRationale)
+	}
+
+	var text bytes.Buffer
+	if err := eval.WriteText(&text, report); err != nil || !strings.Contains(text.String(), "svb-collapse") || !strings.Contains(text.String(), "MEAN") {
+		t.Errorf("Unexpected text report: %s", text.String())
+	}
+}
+
+func TestEvalBaselineGate(t *testing.T) {
+	results := []eval.EventResult{{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 0.8, DirectionAccuracy: 0.8, Brier: 0.1, TagCoverage: 1, Overall: 0.9}}}
+	baseline := eval.NewReport(results)
+	path := t.TempDir() + "/baseline.json"
+	if err := writeReport(path, baseline); err != nil {
+		t.Fatal(err)
+	}
+
+	better := eval.NewReport([]eval.EventResult{{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 0.79, DirectionAccuracy: 0.9, Brier: 0.05, TagCoverage: 1, Overall: 0.93}}})
+	var out bytes.Buffer
+	if err := compareWithBaseline(&out, path, better, 0.02); err != nil {
+		t.Errorf("Expected a drop within tolerance to pass, got: %v", err)
+	}
+
+	worse := eval.NewReport([]eval.EventResult{{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 0.8, DirectionAccuracy: 0.8, Brier: 0.3, TagCoverage: 1, Overall: 0.86}}})
+	out.Reset()
+	err := compareWithBaseline(&out, path, worse, 0.02)
+	if err == nil || !strings.Contains(err.Error(), "brier, overall") || !strings.Contains(out.String(), "REGRESSED") {
+		t.Errorf("Expected brier and overall regressions, got %v:\n%s", err, out.String())
+	}
+}
+
+// Tests for backtesting
+
+func writePrices(t *testing.T, dir, symbol string, rows ...string) {
+	t.Helper()
+	content := "Date,Open,High,Low,Close,Adj Close,Volume\n" + strings.Join(rows, "\n") + "\n"
+	if err := os.WriteFile(dir+"/"+symbol+".csv", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBacktestAgainstPrices(t *testing.T) {
+	dir := t.TempDir()
+	writePrices(t, dir, "XLF",
+		"2023-03-10,35,35,33,34,34,100",
+		"2023-04-10,31,32,30,31,31,100",
+		"2023-04-14,31,32,31,31.5,31.5,100")
+
+	var input string
+	llmClient := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses", "sectors": ["Financials"], "direction": "bearish", "confidence": 0.8, "critique": "Likely"}]}`
+		}
+		input = body
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses"}]}`
+	})
+	events := []backtest.Event{{ID: "svb", Date: "2023-03-10", Event: "Silicon Valley Bank collapses"}}
+	report, err := backtestEvents(llmClient, events, backtest.NewPrices(dir), backtest.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(input, "2023-03-10") {
+		t.Errorf("Expected the event date in the prompt, got: %s", input)
+	}
+	if len(report.Results) != 1 || report.Results[0].EventID != "svb" || report.Results[0].Score != 1 || report.Summary.Resolved != 1 {
+		t.Errorf("Expected the prediction checked against XLF as of the event date, got %+v", report)
+	}
+}
+
+// Tests for retrieval-augmented predictions
+
+func TestRunIndex(t *testing.T) {
+	indexPath := t.TempDir() + "/index.json"
+	var out bytes.Buffer
+	if err := runIndex([]string{"-dir", "../internal/retrieval/testdata/notes", "-out", indexPath, "-passage-words", "8"}, &out); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Indexed 4 passages") {
+		t.Errorf("Expected 4 passages from the text and Markdown files, got: %s", out.String())
+	}
+	idx, err := retrieval.Load(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to load index: %v", err)
+	}
+	if results := idx.Search("crude oil production", 5); len(results) != 1 || results[0].ID != "energy/oil.txt#1" {
+		t.Errorf("Expected the saved index searchable, got %+v", results)
+	}
+}
+
+// Tests for confidence calibration
+
+func TestLoadCalibrator(t *testing.T) {
+	var records []calibration.Record
+	for i := 0; i < 30; i++ {
+		records = append(records, calibration.Record{Model: "openai/o1-mini", Confidence: 0.8, Outcome: i%2 == 0})
+	}
+	data, _ := json.Marshal(records)
+	path := t.TempDir() + "/resolved.json"
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	calibrator, err := loadCalibrator(path, calibration.MethodIsotonic, 20)
+	if err != nil {
+		t.Fatalf("Failed to fit calibration: %v", err)
+	}
+	result := models.CritiquedResponse{Backend: "openai/o1-mini", Predictions: []models.CritiquedPrediction{{Confidence: 0.8}}}
+	if err := calibrator.Apply(&result); err != nil || result.Predictions[0].Confidence != 0.5 {
+		t.Errorf("Expected 0.8 calibrated to 0.5, got %+v, %v", result.Predictions[0], err)
+	}
+
+	if calibrator, err := loadCalibrator("", calibration.MethodIsotonic, 20); calibrator != nil || err != nil {
+		t.Errorf("Expected no calibrator without a records file, got %v, %v", calibrator, err)
+	}
+	if _, err := loadCalibrator(path, "histogram", 20); err == nil {
+		t.Error("Expected an unknown calibration method to be rejected")
+	}
+}
+
+// Tests for the feed watcher
+
+func TestWatchFeeds(t *testing.T) {
+	rssFeed, err := os.ReadFile("../internal/feeds/testdata/rss.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomFeed, err := os.ReadFile("../internal/feeds/testdata/atom.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	rss := string(rssFeed)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/rss":
+			io.WriteString(w, rss)
+		case "/atom":
+			w.Write(atomFeed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	state := dir + "/seen.json"
+	seen, err := feeds.LoadSeen(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inputs []string
+	fail := "Fed holds rates"
+	w := &watcher{
+		httpClient: server.Client(),
+		feeds:      []string{server.URL + "/rss", server.URL + "/missing", server.URL + "/atom"},
+		seen:       seen,
+		generate: func(input string) (*models.CritiquedResponse, error) {
+			inputs = append(inputs, input)
+			if input == fail {
+				return nil, errors.New("pipeline failed")
+			}
+			return &models.CritiquedResponse{OriginalPrompt: input, Predictions: []models.CritiquedPrediction{{Timeframe: "1 month", Description: "d", Impact: "i", Confidence: 0.5, Critique: "c"}}}, nil
+		},
+		write: resultWriter(io.Discard, dir+"/results", "json", nil),
+	}
+
+	if n := w.poll(); n != 2 || len(inputs) != 3 {
+		t.Fatalf("Expected 2 of 3 items processed despite the missing feed, got %d: %q", n, inputs)
+	}
+	files, _ := os.ReadDir(dir + "/results")
+	if len(files) != 2 {
+		t.Errorf("Expected one result file per processed item, got %d", len(files))
+	}
+
+	// The failed item is retried, and the others are skipped after a restart
+	fail = ""
+	seen, err = feeds.LoadSeen(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.seen = seen
+	inputs = nil
+	if n := w.poll(); n != 1 || len(inputs) != 1 || inputs[0] != "Fed holds rates" {
+		t.Errorf("Expected only the failed item retried, got %d: %q", n, inputs)
+	}
+
+	mu.Lock()
+	rss = strings.Replace(string(rssFeed), "<item><title>Fed", "<item><guid>rss-3</guid><title>Bank of Japan ends yield curve control</title></item><item><title>Fed", 1)
+	mu.Unlock()
+	inputs = nil
+	if n := w.poll(); n != 1 || len(inputs) != 1 || inputs[0] != "Bank of Japan ends yield curve control" {
+		t.Errorf("Expected only the new item processed, got %d: %q", n, inputs)
+	}
+
+	// Skipping existing items marks them seen without running the pipeline
+	w.seen, _ = feeds.LoadSeen("")
+	if err := w.markSeen(); err != nil {
+		t.Fatal(err)
+	}
+	inputs = nil
+	if n := w.poll(); n != 0 || len(inputs) != 0 {
+		t.Errorf("Expected existing items skipped, got %d: %q", n, inputs)
+	}
+
+	// An item failing every run is given up on after maxFailures runs, counted across restarts
+	state = dir + "/failing.json"
+	w.seen, _ = feeds.LoadSeen(state)
+	w.maxFailures = 2
+	fail = "Fed holds rates"
+	w.feeds = []string{server.URL + "/rss"}
+	w.poll()
+	w.seen, err = feeds.LoadSeen(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs = nil
+	w.poll()
+	w.poll()
+	if len(inputs) != 1 || inputs[0] != "Fed holds rates" || !w.seen.Has("https://news.example/fed") {
+		t.Errorf("Expected the failing item run once more and then marked seen, got %q", inputs)
+	}
+}
+
+// Tests for result sinks
+
+func TestProfileSinks(t *testing.T) {
+	var attempts int32
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer server.Close()
+
+	t.Setenv("WEBHOOK_URL", server.URL)
+	t.Setenv("WEBHOOK_SECRET", "s3cret")
+	targets, err := profileSinks([]config.Sink{
+		{Type: "webhook", URLEnv: "WEBHOOK_URL", SecretEnv: "WEBHOOK_SECRET", Retry: config.Retry{MaxAttempts: 2, Delay: config.Duration(time.Millisecond)}},
+		{Type: "slack", URL: server.URL},
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create sinks: %v", err)
+	}
+	webhook, ok := targets[0].(*sinks.Webhook)
+	if !ok || webhook.Delivery.URL != server.URL || webhook.Delivery.MaxAttempts != 2 || webhook.Delivery.RetryDelay != time.Millisecond {
+		t.Errorf("Expected a webhook configured from the profile, got %+v", targets[0])
+	}
+	if slack, ok := targets[1].(*sinks.Slack); !ok || slack.Delivery.MaxAttempts != 3 || slack.Delivery.RetryDelay != time.Second {
+		t.Errorf("Expected a Slack sink with the default retries, got %+v", targets[1])
+	}
+
+	result := models.CritiquedResponse{OriginalPrompt: "x", Predictions: []models.CritiquedPrediction{{Timeframe: "1 month", Description: "d", Impact: "i", Confidence: 0.6, Critique: "c"}}}
+	if err := notify(targets[:1], result); err != nil || attempts != 2 {
+		t.Fatalf("Expected the delivery to succeed on retry, got %d attempts: %v", attempts, err)
+	}
+	if timestamp := header.Get(sinks.TimestampHeader); header.Get(sinks.SignatureHeader) != sinks.Sign("s3cret", timestamp, body) {
+		t.Errorf("Expected the delivery signed with the secret from WEBHOOK_SECRET")
+	}
+
+	if _, err := profileSinks([]config.Sink{{Type: "teams", URL: server.URL}}, nil); err == nil {
+		t.Error("Expected an unknown sink type to be rejected")
+	}
+	if _, err := profileSinks([]config.Sink{{Type: "webhook", URL: server.URL, SecretEnv: "UNSET_SECRET"}}, nil); err == nil {
+		t.Error("Expected a missing secret to be rejected")
+	}
+	if _, err := profileSinks([]config.Sink{{Type: "webhook", URLEnv: "UNSET_URL"}}, nil); err == nil {
+		t.Error("Expected a missing URL to be rejected")
+	}
+}
+
+func TestProfileAPIKeysRedacted(t *testing.T) {
+	var logs bytes.Buffer
+	originalLogger := logger.DefaultLogger
+	logger.DefaultLogger = logger.NewWithOptions(logger.Options{Level: slog.LevelDebug, Format: "json", Output: &logs})
+	defer func() { logger.DefaultLogger = originalLogger }()
+
+	t.Setenv("MY_LLM_TOKEN", "tok-3f9a1c")
+	backends := profileBackends([]config.Backend{{Provider: "ollama", Model: "llama3", URL: "http://localhost:11434/v1/chat/completions", APIKeyEnv: "MY_LLM_TOKEN"}})
+	if len(backends) != 1 || backends[0].APIKey != "tok-3f9a1c" {
+		t.Fatalf("Expected the key read from MY_LLM_TOKEN, got %+v", backends)
+	}
+	logger.Error("LLM API call failed", "header", "Authorization: Bearer tok-3f9a1c")
+	if strings.Contains(logs.String(), "tok-3f9a1c") || !strings.Contains(logs.String(), "Bearer [REDACTED]") {
+		t.Errorf("Expected the key masked in logs, got: %s", logs.String())
+	}
+}
+
+func TestSinkURLsRedacted(t *testing.T) {
+	var logs bytes.Buffer
+	originalLogger := logger.DefaultLogger
+	logger.DefaultLogger = logger.NewWithOptions(logger.Options{Level: slog.LevelDebug, Format: "json", Output: &logs})
+	defer func() { logger.DefaultLogger = originalLogger }()
+
+	t.Setenv("HOOK_SECRET", "hmacsecret")
+	client := &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}),
+	}
+	targets, err := profileSinks([]config.Sink{{Type: "slack", URL: "https://hooks.slack.com/services/T0/B0/sinktoken", SecretEnv: "HOOK_SECRET", Retry: config.Retry{MaxAttempts: 1}}}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = notify(targets, models.CritiquedResponse{OriginalPrompt: "x"})
+	if err == nil || strings.Contains(err.Error(), "sinktoken") {
+		t.Errorf("Expected a failed delivery without the URL, got %v", err)
+	}
+	logger.Error("Command failed", "error", fmt.Errorf("posting to https://hooks.slack.com/services/T0/B0/sinktoken with hmacsecret"))
+	if !strings.Contains(logs.String(), "Delivering result failed") || strings.Contains(logs.String(), "sinktoken") || strings.Contains(logs.String(), "hmacsecret") {
+		t.Errorf("Expected the sink URL and secret masked in logs, got: %s", logs.String())
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"nostradamus/internal/logger"
+	"nostradamus/internal/metrics"
+)
+
+// serveMetrics starts serving registry on /metrics at addr in the background and
+// returns the server so the caller can shut it down
+func serveMetrics(addr string, registry *metrics.Registry) (*http.Server, net.Addr, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry.Handler())
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Metrics server stopped", "error", err)
+		}
+	}()
+	logger.Info("Serving metrics", "addr", listener.Addr().String())
+	return server, listener.Addr(), nil
+}
+
+// waitForInterrupt keeps serving metrics until the process receives SIGINT or SIGTERM,
+// then shuts server down
+func waitForInterrupt(server *http.Server) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	logger.Info("Run finished, serving metrics until interrupted")
+	<-signals
+	return server.Shutdown(context.Background())
+}
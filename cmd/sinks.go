@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/logger"
+	"nostradamus/internal/models"
+	"nostradamus/internal/sinks"
+)
+
+// profileSinks creates the sinks of a profile, reading the URLs and secrets named by
+// their *_env fields from the environment. Both are masked in logs, since webhook URLs
+// such as Slack's embed their token.
+func profileSinks(configured []config.Sink, client *http.Client) ([]sinks.Sink, error) {
+	var created []sinks.Sink
+	for i, c := range configured {
+		url := c.URL
+		if c.URLEnv != "" {
+			url = os.Getenv(c.URLEnv)
+		}
+		if url == "" {
+			return nil, fmt.Errorf("sink %d has no url", i+1)
+		}
+		d := sinks.Delivery{
+			Client:      client,
+			URL:         url,
+			MaxAttempts: 3,
+			RetryDelay:  time.Second,
+		}
+		if c.SecretEnv != "" {
+			d.Secret = os.Getenv(c.SecretEnv)
+			if d.Secret == "" {
+				return nil, fmt.Errorf("sink %d: %s is not set", i+1, c.SecretEnv)
+			}
+		}
+		if c.Retry.MaxAttempts > 0 {
+			d.MaxAttempts = c.Retry.MaxAttempts
+		}
+		if c.Retry.Delay > 0 {
+			d.RetryDelay = time.Duration(c.Retry.Delay)
+		}
+		logger.DefaultLogger.AddSecrets(d.URL, d.Secret)
+		sink, err := sinks.New(c.Type, d)
+		if err != nil {
+			return nil, fmt.Errorf("sink %d: %w", i+1, err)
+		}
+		created = append(created, sink)
+	}
+	return created, nil
+}
+
+// maskURL keeps the scheme and host of a sink URL and masks the rest, where tokens go
+func maskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[REDACTED]"
+	}
+	if u.Path == "" && u.RawQuery == "" && u.User == nil {
+		return raw
+	}
+	return u.Scheme + "://" + u.Host + "/[REDACTED]"
+}
+
+// notify delivers result to every sink, logging each failure and returning them joined
+func notify(targets []sinks.Sink, result models.CritiquedResponse) error {
+	var errs []error
+	for i, sink := range targets {
+		if err := sink.Send(result); err != nil {
+			logger.Error("Delivering result failed", "sink", i+1, "error", err)
+			errs = append(errs, fmt.Errorf("sink %d: %w", i+1, err))
+			continue
+		}
+		logger.Info("Delivered result", "sink", i+1)
+	}
+	return errors.Join(errs...)
+}
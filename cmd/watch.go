@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/feeds"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/logger"
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+	"nostradamus/internal/sinks"
+)
+
+// feedList collects the URLs given with repeated -feed flags
+type feedList []string
+
+func (l *feedList) String() string {
+	return fmt.Sprint(*l)
+}
+
+func (l *feedList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runWatch polls RSS and Atom feeds and generates critiqued predictions for each new item
+func runWatch(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	var feedURLs feedList
+	flags.Var(&feedURLs, "feed", "URL of an RSS or Atom feed to poll, repeat for several; defaults to the profile's feeds")
+	interval := flags.Duration("interval", 5*time.Minute, "wait between polls")
+	once := flags.Bool("once", false, "poll the feeds once and exit")
+	outDir := flags.String("out", "", "directory each result is written to, one JSON file per item, defaults to the profile's storage path; results are printed when neither is set")
+	statePath := flags.String("state", "", "file recording the items already processed, so a restart skips them; kept in memory when empty")
+	skipExisting := flags.Bool("skip-existing", false, "mark the items in the feeds at the first poll as seen without predicting for them")
+	maxFailures := flags.Int("max-failures", 3, "failed runs after which an item is given up on and marked as seen")
+	fetchTimeout := flags.Duration("fetch-timeout", 30*time.Second, "time allowed to download a feed before it is skipped until the next poll")
+	rounds := flags.Int("rounds", 0, "number of predictor/critic refinement rounds after the first critique")
+	format := flags.String("format", "json", "output format of printed results: "+strings.Join(output.Formats, ", ")+", overrides the profile's")
+	var backends backendList
+	flags.Var(&backends, "backend", "LLM backend as provider/model@url, repeat in priority order to fail over; the API key is read from PROVIDER_API_KEY")
+	noNotify := flags.Bool("no-notify", false, "skip delivering results to the profile's sinks")
+	profile := flags.String("profile", "", "config file profile to use, defaults to NOSTRADAMUS_PROFILE or the file's default_profile")
+	flags.Parse(args)
+
+	cfg, err := config.Load(*profile)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if setFlags(flags)["format"] {
+		cfg.Format = *format
+	}
+	config.MaxAttempts = cfg.MaxAttempts
+	config.RetryDelay = time.Duration(cfg.RetryDelay)
+	if *outDir == "" {
+		*outDir = cfg.StoragePath
+	}
+	if len(feedURLs) == 0 {
+		feedURLs = cfg.Feeds
+	}
+	if len(feedURLs) == 0 {
+		return errors.New("no feeds to watch, pass -feed <url> or set feeds in the profile")
+	}
+	if len(backends) == 0 {
+		backends = profileBackends(cfg.Backends)
+	}
+	prompts, err := loadPrompts(cfg.PromptsDir)
+	if err != nil {
+		return fmt.Errorf("loading prompts: %w", err)
+	}
+	var targets []sinks.Sink
+	if !*noNotify {
+		targets, err = profileSinks(cfg.Sinks, http.DefaultClient)
+		if err != nil {
+			return fmt.Errorf("creating sinks: %w", err)
+		}
+	}
+	seen, err := feeds.LoadSeen(*statePath)
+	if err != nil {
+		return fmt.Errorf("loading seen items: %w", err)
+	}
+	llmClient, err := newLLMClient(backends, llm.RateLimit{}, profileRateLimits(cfg.Backends, llm.RateLimit{})...)
+	if err != nil {
+		return fmt.Errorf("creating LLM client: %w", err)
+	}
+
+	w := &watcher{
+		httpClient:  &http.Client{Timeout: *fetchTimeout},
+		feeds:       feedURLs,
+		seen:        seen,
+		maxFailures: *maxFailures,
+		generate: func(input string) (*models.CritiquedResponse, error) {
+			return llm.GenerateCritiquedResponse(input, llmClient, llm.WithDebateRounds(*rounds), llm.WithPrompts(prompts))
+		},
+		write: resultWriter(stdout, *outDir, cfg.Format, targets),
+	}
+	if *skipExisting {
+		if err := w.markSeen(); err != nil {
+			return err
+		}
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	for {
+		w.poll()
+		if *once {
+			return nil
+		}
+		select {
+		case <-stop:
+			logger.Info("Stopped watching feeds")
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// watcher runs the prediction pipeline for the items of feeds it has not seen yet
+type watcher struct {
+	httpClient *http.Client
+	feeds      []string
+	seen       *feeds.Seen
+	// maxFailures is the number of failed runs after which an item is marked as seen
+	maxFailures int
+	generate    func(input string) (*models.CritiquedResponse, error)
+	write       func(item feeds.Item, result *models.CritiquedResponse) error
+}
+
+// poll fetches every feed and processes its new items, returning how many succeeded. A
+// feed that cannot be fetched or an item whose run fails is logged and skipped; the item
+// is retried at the next poll until it has failed maxFailures times.
+func (w *watcher) poll() int {
+	processed := 0
+	for _, url := range w.feeds {
+		items, err := feeds.Fetch(w.httpClient, url)
+		if err != nil {
+			logger.Error("Fetching feed failed", "feed", url, "error", err)
+			continue
+		}
+		for _, item := range items {
+			if item.ID == "" || w.seen.Has(item.ID) {
+				continue
+			}
+			logger.Info("New feed item", "feed", url, "title", item.Title)
+			result, err := w.generate(item.Input())
+			if err != nil {
+				logger.Error("Predicting for feed item failed", "feed", url, "title", item.Title, "error", err)
+				w.failed(item)
+				continue
+			}
+			if err := w.write(item, result); err != nil {
+				logger.Error("Writing result failed", "title", item.Title, "error", err)
+				w.failed(item)
+				continue
+			}
+			if err := w.seen.Add(item.ID); err != nil {
+				logger.Error("Recording seen item failed", "title", item.Title, "error", err)
+			}
+			processed++
+		}
+	}
+	return processed
+}
+
+// failed records a failed run for item, marking it as seen once it has failed maxFailures
+// times so it is not run again at every poll
+func (w *watcher) failed(item feeds.Item) {
+	n, err := w.seen.Fail(item.ID)
+	if err != nil {
+		logger.Error("Recording failed item failed", "title", item.Title, "error", err)
+	}
+	if w.maxFailures > 0 && n >= w.maxFailures {
+		logger.Error("Giving up on feed item", "title", item.Title, "failures", n)
+		if err := w.seen.Add(item.ID); err != nil {
+			logger.Error("Recording seen item failed", "title", item.Title, "error", err)
+		}
+	}
+}
+
+// markSeen records every item currently in the feeds as seen. A feed that cannot be
+// fetched is logged and skipped, so its items are processed once it can be.
+func (w *watcher) markSeen() error {
+	for _, url := range w.feeds {
+		items, err := feeds.Fetch(w.httpClient, url)
+		if err != nil {
+			logger.Error("Fetching feed failed", "feed", url, "error", err)
+			continue
+		}
+		for _, item := range items {
+			if err := w.seen.Add(item.ID); err != nil {
+				return fmt.Errorf("recording seen items: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// resultWriter returns a function saving each result to dir, or rendering it in format to
+// stdout when dir is empty, and then delivering it to targets. Failed deliveries are only
+// logged, so the item is not predicted for again.
+func resultWriter(stdout io.Writer, dir, format string, targets []sinks.Sink) func(feeds.Item, *models.CritiquedResponse) error {
+	return func(item feeds.Item, result *models.CritiquedResponse) error {
+		if dir == "" {
+			if err := output.Render(stdout, format, *result); err != nil {
+				return err
+			}
+		} else {
+			path, err := saveResult(dir, result)
+			if err != nil {
+				return err
+			}
+			logger.Info("Saved result", "path", path, "title", item.Title)
+		}
+		notify(targets, *result)
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package analogs
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/similarity"
+)
+
+// MinSimilarity is the similarity below which an analog is not considered comparable
+const MinSimilarity = 0.05
+
+//go:embed analogs.json
+var bundledAnalogs []byte
+
+// Dataset is a set of past market events searchable by similarity to a new event
+type Dataset struct {
+	analogs []models.Analog
+	dates   []time.Time
+	// vectors are the TF-IDF weights of the terms of each analog's event and summary
+	vectors []map[string]float64
+	idf     map[string]float64
+}
+
+// Bundled returns the bundled dataset of past market events
+func Bundled() (*Dataset, error) {
+	return Load(bytes.NewReader(bundledAnalogs))
+}
+
+// Load decodes a JSON array of analogs, in the format of the bundled dataset and of the
+// evaluation dataset, and checks each is valid
+func Load(r io.Reader) (*Dataset, error) {
+	var analogs []models.Analog
+	if err := json.NewDecoder(r).Decode(&analogs); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, a := range analogs {
+		if err := a.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[a.ID] {
+			return nil, fmt.Errorf("duplicate analog id %q", a.ID)
+		}
+		seen[a.ID] = true
+	}
+	return New(analogs), nil
+}
+
+// New indexes analogs for search; they must be valid
+func New(analogs []models.Analog) *Dataset {
+	d := &Dataset{analogs: analogs, idf: make(map[string]float64)}
+	counts := make([]map[string]float64, len(analogs))
+	for i, a := range analogs {
+		date, _ := time.Parse("2006-01-02", a.Date)
+		d.dates = append(d.dates, date)
+		counts[i] = termCounts(a.Event + " " + a.Summary)
+		for t := range counts[i] {
+			d.idf[t]++
+		}
+	}
+	for t, n := range d.idf {
+		d.idf[t] = math.Log(1 + float64(len(analogs))/n)
+	}
+	for _, c := range counts {
+		d.vectors = append(d.vectors, d.weigh(c))
+	}
+	return d
+}
+
+// Analogs returns the analogs of the dataset
+func (d *Dataset) Analogs() []models.Analog {
+	return d.analogs
+}
+
+// Find returns the k analogs most similar to event that happened before the given date,
+// most similar first, with their similarity set. Analogs less similar than MinSimilarity
+// are never returned.
+func (d *Dataset) Find(event string, before time.Time, k int) []models.Analog {
+	query := d.weigh(termCounts(event))
+	var matches []models.Analog
+	for i, a := range d.analogs {
+		if !d.dates[i].Before(before) {
+			continue
+		}
+		if score := cosine(query, d.vectors[i]); score >= MinSimilarity {
+			a.Similarity = score
+			matches = append(matches, a)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// weigh returns the TF-IDF vector of term counts, ignoring terms absent from the dataset
+func (d *Dataset) weigh(counts map[string]float64) map[string]float64 {
+	vector := make(map[string]float64, len(counts))
+	for t, n := range counts {
+		if idf, ok := d.idf[t]; ok {
+			vector[t] = n * idf
+		}
+	}
+	return vector
+}
+
+// termCounts counts the tokens of text
+func termCounts(text string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, t := range similarity.Tokens(text) {
+		counts[t]++
+	}
+	return counts
+}
+
+// cosine returns the cosine similarity of two sparse vectors
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for t, w := range a {
+		dot += w * b[t]
+		normA += w * w
+	}
+	for _, w := range b {
+		normB += w * w
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
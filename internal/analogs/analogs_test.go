@@ -0,0 +1,52 @@
+package analogs_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/analogs"
+	"nostradamus/internal/models"
+)
+
+func TestFindAnalogs(t *testing.T) {
+	dataset, err := analogs.Bundled()
+	if err != nil {
+		t.Fatalf("Failed to load bundled analogs: %v", err)
+	}
+	found := dataset.Find("Iran closes the Strait of Hormuz, cutting Gulf oil supply", time.Now(), 2)
+	if len(found) != 2 || found[0].ID != "iraq-invades-kuwait" || found[0].Similarity <= found[1].Similarity {
+		t.Fatalf("Expected the invasion of Kuwait as the closest analog, got %+v", found)
+	}
+	before := dataset.Find("Iran closes the Strait of Hormuz, cutting Gulf oil supply", time.Date(1990, time.August, 2, 0, 0, 0, 0, time.UTC), 2)
+	if len(before) != 0 {
+		t.Errorf("Expected no analog on or after the as-of date, got %+v", before)
+	}
+	if found := dataset.Find("Semiconductor export licences", time.Now(), 3); len(found) != 0 {
+		t.Errorf("Expected no analog without shared terms, got %+v", found)
+	}
+}
+
+func TestLoadAnalogs(t *testing.T) {
+	// The evaluation dataset can be used as analogs
+	f, err := os.Open("../eval/events.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if dataset, err := analogs.Load(f); err != nil || len(dataset.Analogs()) == 0 {
+		t.Errorf("Expected the evaluation dataset to load as analogs, got %v", err)
+	}
+	if _, err := analogs.Load(strings.NewReader(`[{"id": "x", "date": "2020-01-01", "event": "x", "horizon": "1 month", "outcomes": [{"sector": "Crypto", "direction": "bullish"}]}]`)); err == nil {
+		t.Errorf("Expected an unknown sector error")
+	}
+
+	dataset := analogs.New([]models.Analog{
+		{ID: "opec-cut", Date: "2016-11-30", Event: "OPEC agrees to cut oil production"},
+		{ID: "fed-hike", Date: "2022-03-16", Event: "The Fed raises rates"},
+	})
+	if found := dataset.Find("OPEC+ announces a surprise oil production cut", time.Now(), 5); len(found) != 1 || found[0].ID != "opec-cut" {
+		t.Errorf("Expected only the analog sharing terms, got %+v", found)
+	}
+}
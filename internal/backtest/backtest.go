@@ -0,0 +1,246 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// SectorETFs maps the bundled sectors to the SPDR sector ETFs tracking them
+var SectorETFs = map[string]string{
+	"energy":                 "XLE",
+	"materials":              "XLB",
+	"industrials":            "XLI",
+	"consumer discretionary": "XLY",
+	"consumer staples":       "XLP",
+	"health care":            "XLV",
+	"financials":             "XLF",
+	"information technology": "XLK",
+	"communication services": "XLC",
+	"utilities":              "XLU",
+	"real estate":            "XLRE",
+}
+
+// Event is a dated historical event to run the pipeline on
+type Event struct {
+	ID   string `json:"id"`
+	Date string `json:"date"`
+	// Event describes what happened on Date
+	Event string `json:"event"`
+}
+
+// LoadEvents decodes a JSON array of events, each with an id, a YYYY-MM-DD date and a
+// description. Other fields are ignored, so evaluation datasets can be backtested too.
+func LoadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return nil, err
+	}
+	for i, e := range events {
+		if e.ID == "" || e.Event == "" {
+			return nil, fmt.Errorf("event %d needs an id and an event", i)
+		}
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return nil, fmt.Errorf("event %s: invalid date %q", e.ID, e.Date)
+		}
+	}
+	return events, nil
+}
+
+// Options set the moves that count as a hit
+type Options struct {
+	// MinMove is the return a bullish prediction must exceed, or a bearish one fall below
+	// the negative of, over its timeframe
+	MinMove float64
+	// VolatileMove is the largest move from the starting close, at any point of the
+	// timeframe, that a volatile prediction needs to reach
+	VolatileMove float64
+}
+
+// Check is a prediction compared with the prices of one instrument
+type Check struct {
+	Symbol string `json:"symbol"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// Return is the change of the close from From to To
+	Return float64 `json:"return"`
+	// Excursion is the largest move from the close on From, up or down, up to To
+	Excursion float64 `json:"excursion"`
+	Hit       bool    `json:"hit"`
+}
+
+// Result is a prediction checked against the prices of the instruments it concerns.
+// Confidence is the prediction's unconditional probability, which weighs the hit rate.
+type Result struct {
+	EventID     string  `json:"event_id"`
+	Description string  `json:"description"`
+	Timeframe   string  `json:"timeframe"`
+	Direction   string  `json:"direction,omitempty"`
+	Confidence  float64 `json:"confidence"`
+	Checks      []Check `json:"checks,omitempty"`
+	// Score is the share of checks that hit
+	Score float64 `json:"score"`
+	// Unresolved explains why the prediction could not be checked
+	Unresolved string `json:"unresolved,omitempty"`
+}
+
+// Resolve checks prediction p, made as of asOf, against the tickers it names and the sector
+// ETFs of its sectors. An instrument is checked when prices cover the as-of date and the
+// date the timeframe resolves to.
+func Resolve(eventID string, asOf time.Time, p models.CritiquedPrediction, prices *Prices, o Options) (Result, error) {
+	r := Result{EventID: eventID, Description: p.Description, Timeframe: p.Timeframe, Direction: p.Direction, Confidence: p.Likelihood()}
+	direction := strings.ToLower(p.Direction)
+	if direction == "" {
+		r.Unresolved = "no direction"
+		return r, nil
+	}
+	tf, err := models.ParseTimeframe(p.Timeframe)
+	if err != nil {
+		r.Unresolved = err.Error()
+		return r, nil
+	}
+	end := tf.From(asOf)
+
+	var hits int
+	for _, symbol := range symbols(p) {
+		series, err := prices.Series(symbol)
+		if err != nil {
+			return r, err
+		}
+		from, to := series.onOrBefore(asOf), series.onOrBefore(end)
+		if from < 0 || len(series) == 0 || series[len(series)-1].Date.Before(end) {
+			continue
+		}
+		start := series[from].Close
+		check := Check{
+			Symbol: symbol,
+			From:   series[from].Date.Format("2006-01-02"),
+			To:     series[to].Date.Format("2006-01-02"),
+			Return: series[to].Close/start - 1,
+		}
+		for _, bar := range series[from+1 : to+1] {
+			check.Excursion = math.Max(check.Excursion, math.Max(bar.High/start-1, 1-bar.Low/start))
+		}
+		switch direction {
+		case "bullish":
+			check.Hit = check.Return > o.MinMove
+		case "bearish":
+			check.Hit = check.Return < -o.MinMove
+		case "volatile":
+			check.Hit = check.Excursion >= o.VolatileMove
+		}
+		if check.Hit {
+			hits++
+		}
+		r.Checks = append(r.Checks, check)
+	}
+	if len(r.Checks) == 0 {
+		r.Unresolved = fmt.Sprintf("no prices covering %s to %s for %s", asOf.Format("2006-01-02"), end.Format("2006-01-02"), strings.Join(symbols(p), ", "))
+		return r, nil
+	}
+	r.Score = float64(hits) / float64(len(r.Checks))
+	return r, nil
+}
+
+// symbols returns the tickers of p followed by the ETFs of its sectors, without duplicates
+func symbols(p models.CritiquedPrediction) []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	add := func(symbol string) {
+		symbol = strings.ToUpper(symbol)
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	for _, t := range p.Tickers {
+		add(t)
+	}
+	for _, s := range p.Sectors {
+		add(SectorETFs[strings.ToLower(s)])
+	}
+	return symbols
+}
+
+// Summary aggregates the results of a backtest
+type Summary struct {
+	Predictions int `json:"predictions"`
+	Resolved    int `json:"resolved"`
+	// HitRate is the mean score of the resolved predictions
+	HitRate float64 `json:"hit_rate"`
+	// WeightedHitRate weights each resolved prediction's score by its confidence
+	WeightedHitRate float64 `json:"weighted_hit_rate"`
+}
+
+// Report is the result of a backtest
+type Report struct {
+	Results []Result `json:"results"`
+	// Errors lists the events whose run failed
+	Errors  map[string]string `json:"errors,omitempty"`
+	Summary Summary           `json:"summary"`
+}
+
+// NewReport summarises results
+func NewReport(results []Result, errors map[string]string) Report {
+	r := Report{Results: results, Errors: errors}
+	r.Summary.Predictions = len(results)
+	var scores, weighted, confidence float64
+	for _, result := range results {
+		if result.Unresolved != "" {
+			continue
+		}
+		r.Summary.Resolved++
+		scores += result.Score
+		weighted += result.Score * result.Confidence
+		confidence += result.Confidence
+	}
+	if r.Summary.Resolved > 0 {
+		r.Summary.HitRate = scores / float64(r.Summary.Resolved)
+	}
+	if confidence > 0 {
+		r.Summary.WeightedHitRate = weighted / confidence
+	}
+	return r
+}
+
+// WriteText writes the checked predictions and the summary of r as a table
+func WriteText(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EVENT\tTIMEFRAME\tDIRECTION\tCONFIDENCE\tSCORE\tCHECKS")
+	for _, result := range r.Results {
+		checks := result.Unresolved
+		if checks == "" {
+			var parts []string
+			for _, c := range result.Checks {
+				parts = append(parts, fmt.Sprintf("%s %+.1f%%", c.Symbol, c.Return*100))
+			}
+			checks = strings.Join(parts, ", ")
+		}
+		score := "-"
+		if result.Unresolved == "" {
+			score = fmt.Sprintf("%.2f", result.Score)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%s\t%s\n", result.EventID, result.Timeframe, result.Direction, result.Confidence, score, checks)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(r.Errors))
+	for id := range r.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(w, "%s: run failed: %s\n", id, r.Errors[id])
+	}
+	_, err := fmt.Fprintf(w, "\nResolved %d of %d predictions, hit rate %.2f, confidence-weighted hit rate %.2f\n",
+		r.Summary.Resolved, r.Summary.Predictions, r.Summary.HitRate, r.Summary.WeightedHitRate)
+	return err
+}
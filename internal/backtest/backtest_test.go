@@ -0,0 +1,105 @@
+package backtest_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/backtest"
+	"nostradamus/internal/models"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	writePrices(t, dir, "XLF",
+		"2023-03-09,35,35,34,35,35,100",
+		"2023-03-10,35,35,33,34,34,100",
+		"2023-03-17,33,33,31,32,32,100",
+		"2023-04-10,31,32,30,31,31,100",
+		"2023-04-14,31,32,31,31.5,31.5,100")
+	writePrices(t, dir, "XLE",
+		"2023-03-10,81,82,80,81,81,100",
+		"2023-04-10,78,79,77,78,78,100",
+		"2023-04-14,79,80,78,79,79,100")
+	writePrices(t, dir, "JPM",
+		"2023-03-10,130,131,129,130,130,100",
+		"2023-03-17,128,132,117,125,125,100",
+		"2023-04-14,126,127,125,126,126,100")
+
+	asOf := time.Date(2023, time.March, 10, 0, 0, 0, 0, time.UTC)
+	prices := backtest.NewPrices(dir)
+	var results []backtest.Result
+	for _, p := range []models.CritiquedPrediction{
+		{Timeframe: "1 month", Description: "Banks fall", Sectors: []string{"Financials"}, Direction: "bearish", Confidence: 0.8},
+		{Timeframe: "1 month", Description: "Oil rallies", Sectors: []string{"Energy"}, Direction: "bullish", Confidence: 0.2},
+		{Timeframe: "1 month", Description: "Pharma rallies", Sectors: []string{"Health Care"}, Direction: "bullish", Confidence: 0.6},
+		{Timeframe: "1 week", Description: "JPM swings", Tickers: []string{"jpm"}, Direction: "volatile", Confidence: 0.5},
+		{Timeframe: "1 week", Description: "Something happens", Tickers: []string{"JPM"}, Confidence: 0.5},
+	} {
+		result, err := backtest.Resolve("svb", asOf, p, prices, backtest.Options{VolatileMove: 0.05})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		results = append(results, result)
+	}
+
+	banks := results[0]
+	if banks.Score != 1 || len(banks.Checks) != 1 || banks.Checks[0].Symbol != "XLF" || banks.Checks[0].From != "2023-03-10" || banks.Checks[0].To != "2023-04-10" {
+		t.Errorf("Unexpected check of the banks prediction: %+v", banks)
+	}
+	if math.Abs(banks.Checks[0].Return-(31.0/34-1)) > 1e-9 {
+		t.Errorf("Expected the XLF return from close to close, got %v", banks.Checks[0].Return)
+	}
+	if results[1].Score != 0 || !strings.Contains(results[2].Unresolved, "XLV") {
+		t.Errorf("Expected the oil prediction missed and the pharma one without prices, got %+v", results[1:3])
+	}
+	// JPM's low of 117 is 10% under the starting close of 130
+	if jpm := results[3]; jpm.Score != 1 || jpm.Checks[0].Symbol != "JPM" || math.Abs(jpm.Checks[0].Excursion-0.1) > 1e-9 {
+		t.Errorf("Expected the JPM swing hit, got %+v", jpm)
+	}
+	if results[4].Unresolved != "no direction" {
+		t.Errorf("Expected a prediction without direction unresolved, got %+v", results[4])
+	}
+
+	report := backtest.NewReport(results[:4], map[string]string{"ftx": "critique: timed out"})
+	if report.Summary.Predictions != 4 || report.Summary.Resolved != 3 {
+		t.Fatalf("Expected 3 of 4 predictions resolved, got %+v", report.Summary)
+	}
+	if math.Abs(report.Summary.HitRate-2.0/3) > 1e-9 || math.Abs(report.Summary.WeightedHitRate-1.3/1.5) > 1e-9 {
+		t.Errorf("Unexpected summary: %+v", report.Summary)
+	}
+	var out bytes.Buffer
+	if err := backtest.WriteText(&out, report); err != nil || !strings.Contains(out.String(), "XLF -8.8%") || !strings.Contains(out.String(), "hit rate 0.67, confidence-weighted hit rate 0.87") || !strings.Contains(out.String(), "ftx") {
+		t.Errorf("Unexpected text report: %s", out.String())
+	}
+}
+
+func TestResolveWeightsByProbability(t *testing.T) {
+	r := models.CritiquedResponse{Predictions: []models.CritiquedPrediction{
+		{ID: "p1", Timeframe: "2 weeks", Description: "Gas supply to Europe drops", Confidence: 0.8},
+		{ID: "p2", Parents: []string{"p1"}, Timeframe: "3 months", Description: "Airline margins shrink", Sectors: []string{"Industrials"}, Direction: "bearish", Confidence: 0.5},
+	}}
+	if err := r.PropagateConfidence(); err != nil {
+		t.Fatal(err)
+	}
+	// p2 happens with probability 0.5 given p1, so 0.5 * 0.8 overall
+	result, err := backtest.Resolve("gas", time.Now(), r.Predictions[1], backtest.NewPrices(t.TempDir()), backtest.Options{})
+	if err != nil || math.Abs(result.Confidence-0.4) > 1e-9 {
+		t.Errorf("Expected the backtest weighted by the overall probability 0.4, got %+v, %v", result, err)
+	}
+}
+
+func TestLoadEvents(t *testing.T) {
+	events, err := backtest.LoadEvents(strings.NewReader(`[{"id": "svb", "date": "2023-03-10", "event": "Silicon Valley Bank collapses", "outcomes": []}]`))
+	if err != nil || len(events) != 1 || events[0].ID != "svb" {
+		t.Errorf("Expected the event loaded with other fields ignored, got %+v, %v", events, err)
+	}
+	if _, err := backtest.LoadEvents(strings.NewReader(`[{"id": "svb", "date": "March 2023", "event": "SVB"}]`)); err == nil || !strings.Contains(err.Error(), "invalid date") {
+		t.Errorf("Expected an invalid date rejected, got: %v", err)
+	}
+	if _, err := backtest.LoadEvents(strings.NewReader(`[{"date": "2023-03-10", "event": "SVB"}]`)); err == nil {
+		t.Errorf("Expected an event without id rejected")
+	}
+}
@@ -0,0 +1,132 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bar is the daily open, high, low and close of an instrument
+type Bar struct {
+	Date  time.Time
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// Series is the daily bars of an instrument in date order
+type Series []Bar
+
+// LoadSeries reads daily bars from CSV with a header row naming the "date", "open",
+// "high", "low" and "close" columns, in any order; other columns such as volume are
+// ignored. Dates are given as YYYY-MM-DD.
+func LoadSeries(r io.Reader) (Series, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("price file is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"date", "open", "high", "low", "close"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("price file is missing the %q column", name)
+		}
+	}
+
+	var series Series
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[columns["date"]]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date: %w", line, err)
+		}
+		bar := Bar{Date: date}
+		for name, field := range map[string]*float64{"open": &bar.Open, "high": &bar.High, "low": &bar.Low, "close": &bar.Close} {
+			if *field, err = strconv.ParseFloat(strings.TrimSpace(record[columns[name]]), 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, name, err)
+			}
+		}
+		series = append(series, bar)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Date.Before(series[j].Date) })
+	return series, nil
+}
+
+// onOrBefore returns the index of the last bar dated on or before t, or -1
+func (s Series) onOrBefore(t time.Time) int {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Date.After(t) })
+	return i - 1
+}
+
+// Prices loads the series of instruments on demand from a directory holding one
+// SYMBOL.csv file per instrument. It is safe for concurrent use.
+type Prices struct {
+	dir    string
+	mu     sync.Mutex
+	series map[string]Series
+}
+
+// NewPrices reads price files from dir
+func NewPrices(dir string) *Prices {
+	return &Prices{dir: dir, series: make(map[string]Series)}
+}
+
+// symbolPattern matches the ticker symbols a price file may be named after, keeping
+// symbols given by the LLM from naming files outside the directory
+var symbolPattern = regexp.MustCompile(`^[A-Za-z0-9.^=-]+$`)
+
+// Series returns the bars of symbol, or nil when the directory has no file for it or
+// symbol is not a valid ticker
+func (p *Prices) Series(symbol string) (Series, error) {
+	symbol = strings.ToUpper(symbol)
+	if !symbolPattern.MatchString(symbol) {
+		return nil, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s, ok := p.series[symbol]; ok {
+		return s, nil
+	}
+	f, err := os.Open(filepath.Join(p.dir, symbol+".csv"))
+	if errors.Is(err, fs.ErrNotExist) {
+		p.series[symbol] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := LoadSeries(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s.csv: %w", symbol, err)
+	}
+	p.series[symbol] = s
+	return s, nil
+}
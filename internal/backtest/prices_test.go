@@ -0,0 +1,52 @@
+package backtest_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/backtest"
+	"nostradamus/internal/models"
+)
+
+// writePrices writes rows of daily prices as dir/symbol.csv, in the format of Yahoo
+// Finance's downloads
+func writePrices(t *testing.T, dir, symbol string, rows ...string) {
+	t.Helper()
+	content := "Date,Open,High,Low,Close,Adj Close,Volume\n" + strings.Join(rows, "\n") + "\n"
+	if err := os.WriteFile(dir+"/"+symbol+".csv", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadSeriesErrors(t *testing.T) {
+	if _, err := backtest.LoadSeries(strings.NewReader("Date,Close\n2023-01-01,1\n")); err == nil || !strings.Contains(err.Error(), `"open"`) {
+		t.Errorf("Expected missing column error, got: %v", err)
+	}
+	if _, err := backtest.LoadSeries(strings.NewReader("Date,Open,High,Low,Close\n01/02/2023,1,1,1,1\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected invalid date error, got: %v", err)
+	}
+	series, err := backtest.NewPrices(t.TempDir()).Series("SPY")
+	if err != nil || series != nil {
+		t.Errorf("Expected no series for a missing file, got %v, %v", series, err)
+	}
+
+	// A ticker naming a file outside the prices directory leaves the prediction unresolved
+	root := t.TempDir()
+	if err := os.Mkdir(root+"/prices", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(root+"/SECRET.csv", []byte("Date,Open,High,Low,Close\n2023-01-02,1,1,1,1\n2023-12-29,2,2,2,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prices := backtest.NewPrices(root + "/prices")
+	if series, err := prices.Series("../SECRET"); err != nil || series != nil {
+		t.Errorf("Expected a path as ticker rejected, got %v, %v", series, err)
+	}
+	p := models.CritiquedPrediction{Timeframe: "1 month", Direction: "bullish", Tickers: []string{"../SECRET"}, Confidence: 0.5}
+	result, err := backtest.Resolve("e", time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), p, prices, backtest.Options{})
+	if err != nil || result.Unresolved == "" || len(result.Checks) != 0 {
+		t.Errorf("Expected the prediction unresolved, got %+v, %v", result, err)
+	}
+}
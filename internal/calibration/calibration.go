@@ -0,0 +1,299 @@
+package calibration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// Calibration methods
+const (
+	// MethodPlatt fits a logistic curve to the logit of the confidences
+	MethodPlatt = "platt"
+	// MethodIsotonic fits a non-decreasing step function by pool adjacent violators
+	MethodIsotonic = "isotonic"
+)
+
+// Methods lists the supported calibration methods
+var Methods = []string{MethodPlatt, MethodIsotonic}
+
+// Record is a past prediction whose outcome is known
+type Record struct {
+	// Model is the provider/model of the critic, as in CritiquedResponse.Backend
+	Model string `json:"model"`
+	// Persona is the critic persona, as in CritiquedResponse.Persona
+	Persona    string  `json:"persona,omitempty"`
+	Confidence float64 `json:"confidence"`
+	// Outcome is whether the prediction came true
+	Outcome bool `json:"outcome"`
+}
+
+// LoadRecords decodes a JSON array of resolved predictions and checks their confidences
+// lie in [0, 1]
+func LoadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	for i, rec := range records {
+		if rec.Confidence < 0 || rec.Confidence > 1 || math.IsNaN(rec.Confidence) {
+			return nil, fmt.Errorf("record %d: confidence %v out of [0, 1]", i, rec.Confidence)
+		}
+	}
+	return records, nil
+}
+
+// Map turns raw confidences into calibrated probabilities
+type Map interface {
+	Apply(confidence float64) float64
+}
+
+// key selects the records a map is fitted on; an empty field matches any value
+type key struct {
+	model, persona string
+}
+
+// Calibrator holds the maps fitted for each model, each persona and each pair of the two
+type Calibrator struct {
+	method string
+	maps   map[key]Map
+	counts map[key]int
+}
+
+// Fit fits a map with method on all records, and one on the records of each model,
+// persona and model and persona pair with at least minRecords of them
+func Fit(records []Record, method string, minRecords int) (*Calibrator, error) {
+	if method != MethodPlatt && method != MethodIsotonic {
+		return nil, fmt.Errorf("unknown calibration method %q, expected one of %s", method, strings.Join(Methods, ", "))
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no resolved predictions to fit on")
+	}
+	groups := make(map[key][]Record)
+	for _, rec := range records {
+		model, persona := strings.ToLower(rec.Model), strings.ToLower(rec.Persona)
+		groups[key{}] = append(groups[key{}], rec)
+		if model != "" {
+			groups[key{model: model}] = append(groups[key{model: model}], rec)
+		}
+		if persona != "" {
+			groups[key{persona: persona}] = append(groups[key{persona: persona}], rec)
+		}
+		if model != "" && persona != "" {
+			groups[key{model, persona}] = append(groups[key{model, persona}], rec)
+		}
+	}
+	c := &Calibrator{method: method, maps: make(map[key]Map), counts: make(map[key]int)}
+	for k, group := range groups {
+		if k != (key{}) && len(group) < minRecords {
+			continue
+		}
+		confidences := make([]float64, len(group))
+		outcomes := make([]bool, len(group))
+		for i, rec := range group {
+			confidences[i], outcomes[i] = rec.Confidence, rec.Outcome
+		}
+		if method == MethodPlatt {
+			c.maps[k] = FitPlatt(confidences, outcomes)
+		} else {
+			c.maps[k] = FitIsotonic(confidences, outcomes)
+		}
+		c.counts[k] = len(group)
+	}
+	return c, nil
+}
+
+// lookup returns the most specific map for model and persona: the pair's, else the
+// model's, else the persona's, else the one fitted on all records
+func (c *Calibrator) lookup(model, persona string) (key, Map) {
+	model, persona = strings.ToLower(model), strings.ToLower(persona)
+	for _, k := range []key{{model, persona}, {model: model}, {persona: persona}, {}} {
+		if m, ok := c.maps[k]; ok {
+			return k, m
+		}
+	}
+	return key{}, nil
+}
+
+// Apply replaces the confidence of every prediction of r with its calibrated value,
+// keeping the critic's in RawConfidence, and records the map used. Probabilities are
+// propagated again from the calibrated confidences when the predictions form a graph.
+func (c *Calibrator) Apply(r *models.CritiquedResponse) error {
+	k, m := c.lookup(r.Backend, r.Persona)
+	if m == nil {
+		return nil
+	}
+	for i := range r.Predictions {
+		p := &r.Predictions[i]
+		if p.RawConfidence == nil {
+			raw := p.Confidence
+			p.RawConfidence = &raw
+		}
+		p.Confidence = m.Apply(*p.RawConfidence)
+	}
+	r.Calibration = &models.Calibration{Method: c.method, Model: k.model, Persona: k.persona, Records: c.counts[k]}
+	if r.HasGraph() {
+		return r.PropagateConfidence()
+	}
+	return nil
+}
+
+// Platt maps a confidence c to 1 / (1 + exp(A * logit(c) + B))
+type Platt struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// epsilon keeps confidences of 0 and 1 away from infinite logits
+const epsilon = 1e-6
+
+// logit returns the log odds of c, clamped to [epsilon, 1 - epsilon]
+func logit(c float64) float64 {
+	c = math.Min(math.Max(c, epsilon), 1-epsilon)
+	return math.Log(c / (1 - c))
+}
+
+// Apply returns the calibrated probability of confidence
+func (p Platt) Apply(confidence float64) float64 {
+	return 1 / (1 + math.Exp(p.A*logit(confidence)+p.B))
+}
+
+// FitPlatt fits a Platt map by Newton's method on the regularised targets of Platt (1999),
+// which keep a perfectly separated sample from driving the slope to infinity
+func FitPlatt(confidences []float64, outcomes []bool) Platt {
+	var positives, negatives float64
+	for _, o := range outcomes {
+		if o {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	hi, lo := (positives+1)/(positives+2), 1/(negatives+2)
+	x := make([]float64, len(confidences))
+	t := make([]float64, len(confidences))
+	for i, c := range confidences {
+		x[i] = logit(c)
+		t[i] = lo
+		if outcomes[i] {
+			t[i] = hi
+		}
+	}
+
+	// Start from the identity, A = -1 and B = 0, and minimise the cross-entropy
+	p := Platt{A: -1}
+	const lambda = 1e-9
+	for iteration := 0; iteration < 100; iteration++ {
+		var gA, gB, hAA, hAB, hBB float64
+		for i := range x {
+			q := p.Apply(confidences[i])
+			// d(loss)/d(A x + B) is t - q for q = 1 / (1 + exp(A x + B))
+			d := t[i] - q
+			w := q * (1 - q)
+			gA += d * x[i]
+			gB += d
+			hAA += w * x[i] * x[i]
+			hAB += w * x[i]
+			hBB += w
+		}
+		hAA += lambda
+		hBB += lambda
+		det := hAA*hBB - hAB*hAB
+		if det == 0 {
+			break
+		}
+		dA := (hBB*gA - hAB*gB) / det
+		dB := (hAA*gB - hAB*gA) / det
+		p.A -= dA
+		p.B -= dB
+		if math.Abs(dA) < 1e-10 && math.Abs(dB) < 1e-10 {
+			break
+		}
+	}
+	return p
+}
+
+// Isotonic maps a confidence to the outcome rate of its block of past confidences,
+// interpolating linearly between blocks
+type Isotonic struct {
+	// X are the mean confidences of the blocks, increasing
+	X []float64 `json:"x"`
+	// Y are the outcome rates of the blocks, non-decreasing
+	Y []float64 `json:"y"`
+}
+
+// Apply returns the calibrated probability of confidence, clamped to the rates of the
+// first and last blocks outside their confidences
+func (m Isotonic) Apply(confidence float64) float64 {
+	n := len(m.X)
+	switch {
+	case n == 0:
+		return confidence
+	case confidence <= m.X[0]:
+		return m.Y[0]
+	case confidence >= m.X[n-1]:
+		return m.Y[n-1]
+	}
+	i := sort.SearchFloat64s(m.X, confidence)
+	if m.X[i] == confidence {
+		return m.Y[i]
+	}
+	share := (confidence - m.X[i-1]) / (m.X[i] - m.X[i-1])
+	return m.Y[i-1] + share*(m.Y[i]-m.Y[i-1])
+}
+
+// FitIsotonic fits the non-decreasing map closest to the outcomes in least squares, by
+// pooling adjacent blocks of confidences whose outcome rates decrease
+func FitIsotonic(confidences []float64, outcomes []bool) Isotonic {
+	order := make([]int, len(confidences))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return confidences[order[a]] < confidences[order[b]] })
+
+	// Equal confidences start in one block so they map to one rate
+	type block struct {
+		sumX, sumY, n float64
+	}
+	var blocks []block
+	for k, i := range order {
+		y := 0.0
+		if outcomes[i] {
+			y = 1
+		}
+		if k > 0 && confidences[order[k-1]] == confidences[i] {
+			last := &blocks[len(blocks)-1]
+			last.sumX += confidences[i]
+			last.sumY += y
+			last.n++
+			continue
+		}
+		blocks = append(blocks, block{sumX: confidences[i], sumY: y, n: 1})
+	}
+
+	var pooled []block
+	for _, b := range blocks {
+		pooled = append(pooled, b)
+		for len(pooled) > 1 {
+			last := len(pooled) - 1
+			if pooled[last-1].sumY/pooled[last-1].n <= pooled[last].sumY/pooled[last].n {
+				break
+			}
+			pooled[last-1].sumX += pooled[last].sumX
+			pooled[last-1].sumY += pooled[last].sumY
+			pooled[last-1].n += pooled[last].n
+			pooled = pooled[:last]
+		}
+	}
+	m := Isotonic{}
+	for _, b := range pooled {
+		m.X = append(m.X, b.sumX/b.n)
+		m.Y = append(m.Y, b.sumY/b.n)
+	}
+	return m
+}
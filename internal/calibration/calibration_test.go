@@ -0,0 +1,108 @@
+package calibration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/calibration"
+	"nostradamus/internal/models"
+)
+
+func TestIsotonicCalibration(t *testing.T) {
+	confidences := []float64{0.9, 0.9, 0.9, 0.9, 0.7, 0.7, 0.5, 0.5, 0.3, 0.3}
+	outcomes := []bool{true, true, false, false, true, false, true, false, false, false}
+	m := calibration.FitIsotonic(confidences, outcomes)
+	for i := 1; i < len(m.Y); i++ {
+		if m.X[i] <= m.X[i-1] || m.Y[i] < m.Y[i-1] {
+			t.Fatalf("Expected an increasing map, got %+v", m)
+		}
+	}
+	// 0.5, 0.7 and 0.9 all came true half the time, so they pool into one block
+	if m.Apply(0.3) != 0 || m.Apply(0.9) != 0.5 || m.Apply(0.7) != 0.5 || m.Apply(1) != 0.5 {
+		t.Errorf("Unexpected isotonic map %+v", m)
+	}
+	if got := m.Apply(0.4); math.Abs(got-0.25) > 1e-9 {
+		t.Errorf("Expected 0.4 interpolated to 0.25, got %v", got)
+	}
+}
+
+func TestPlattCalibration(t *testing.T) {
+	var confidences []float64
+	var outcomes []bool
+	for i := 0; i < 100; i++ {
+		confidences = append(confidences, 0.9, 0.6)
+		outcomes = append(outcomes, i%10 < 6, i%10 < 4)
+	}
+	p := calibration.FitPlatt(confidences, outcomes)
+	if got := p.Apply(0.9); math.Abs(got-0.6) > 0.02 {
+		t.Errorf("Expected 0.9 calibrated to about 0.6, got %v (%+v)", got, p)
+	}
+	if got := p.Apply(0.6); math.Abs(got-0.4) > 0.02 {
+		t.Errorf("Expected 0.6 calibrated to about 0.4, got %v (%+v)", got, p)
+	}
+	if p.Apply(0.95) <= p.Apply(0.9) {
+		t.Errorf("Expected the Platt map to increase, got %+v", p)
+	}
+}
+
+func TestCalibratorPerModelAndPersona(t *testing.T) {
+	var records []calibration.Record
+	for i := 0; i < 30; i++ {
+		records = append(records,
+			calibration.Record{Model: "openai/o1-mini", Persona: "bear", Confidence: 0.8, Outcome: i%2 == 0},
+			calibration.Record{Model: "openai/o1-mini", Persona: "bull", Confidence: 0.8, Outcome: i%10 < 8},
+		)
+	}
+	records = append(records, calibration.Record{Model: "groq/llama", Persona: "bear", Confidence: 0.8, Outcome: false})
+	data, _ := json.Marshal(records)
+	loaded, err := calibration.LoadRecords(bytes.NewReader(data))
+	if err != nil || len(loaded) != len(records) {
+		t.Fatalf("Failed to load records: %v", err)
+	}
+	calibrator, err := calibration.Fit(loaded, calibration.MethodIsotonic, 20)
+	if err != nil {
+		t.Fatalf("Failed to fit calibration: %v", err)
+	}
+
+	result := models.CritiquedResponse{Backend: "openai/o1-mini", Persona: "bear", Predictions: []models.CritiquedPrediction{{Confidence: 0.8}}}
+	if err := calibrator.Apply(&result); err != nil {
+		t.Fatal(err)
+	}
+	p := result.Predictions[0]
+	if p.Confidence != 0.5 || p.RawConfidence == nil || *p.RawConfidence != 0.8 {
+		t.Errorf("Expected the bear persona calibrated to 0.5 keeping 0.8 as raw, got %+v", p)
+	}
+	if c := result.Calibration; c == nil || c.Model != "openai/o1-mini" || c.Persona != "bear" || c.Records != 30 {
+		t.Errorf("Expected the model and persona map recorded, got %+v", c)
+	}
+
+	// Calibrating again starts from the raw confidence
+	if err := calibrator.Apply(&result); err != nil || result.Predictions[0].Confidence != 0.5 {
+		t.Errorf("Expected calibration to be idempotent, got %+v, %v", result.Predictions[0], err)
+	}
+
+	// groq/llama has a single record, so it falls back to the bear persona's map
+	result = models.CritiquedResponse{Backend: "groq/llama", Persona: "bear", Predictions: []models.CritiquedPrediction{{Confidence: 0.8}}}
+	if err := calibrator.Apply(&result); err != nil {
+		t.Fatal(err)
+	}
+	if c := result.Calibration; c.Model != "" || c.Persona != "bear" || c.Records != 31 {
+		t.Errorf("Expected the persona map used, got %+v", c)
+	}
+
+	if p := result.Predictions[0]; math.Abs(p.Confidence-0.48) > 0.005 {
+		t.Errorf("Expected the bear persona map to calibrate 0.8 to 0.48, got %+v", p)
+	}
+}
+
+func TestLoadRecordsErrors(t *testing.T) {
+	if _, err := calibration.LoadRecords(strings.NewReader(`[{"model": "openai/o1-mini", "confidence": 1.2, "outcome": true}]`)); err == nil || !strings.Contains(err.Error(), "record 0") {
+		t.Errorf("Expected a confidence out of [0, 1] rejected, got: %v", err)
+	}
+	if _, err := calibration.Fit([]calibration.Record{{Confidence: 0.5, Outcome: true}}, "histogram", 20); err == nil {
+		t.Error("Expected an unknown calibration method to be rejected")
+	}
+}
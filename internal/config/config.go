@@ -2,20 +2,74 @@ package config
 
 import (
 	"os"
+	"strings"
 	"time"
 )
 
 // Config holds application configuration
 type Config struct {
-	Debug bool
+	Debug bool `json:"debug"`
+	// LogLevel is one of "debug", "info", "warn", "error" or "off"; empty defers to Debug
+	LogLevel string `json:"log_level,omitempty"`
+	// LogFormat is "text" or "json"
+	LogFormat string `json:"log_format,omitempty"`
+	// LogContent is "full", "truncated" or "hashed" and controls how prompts and responses are logged
+	LogContent string `json:"log_content,omitempty"`
+	// Secrets are values that must never appear in logs, such as the *_API_KEY variables
+	Secrets []string `json:"-"`
+
+	// Path is the config file read by Load, empty when none was found
+	Path string `json:"path,omitempty"`
+	// Profile is the profile applied by Load, empty when none was
+	Profile string `json:"profile,omitempty"`
+	// Backends are called in priority order; empty means OpenAI's o1-mini
+	Backends    []Backend `json:"backends,omitempty"`
+	MaxAttempts int       `json:"max_attempts"`
+	RetryDelay  Duration  `json:"retry_delay"`
+	// Format is the default output format
+	Format string `json:"format"`
+	// StoragePath is the directory results are saved to; empty means they are not saved
+	StoragePath string `json:"storage_path,omitempty"`
+	// PromptsDir holds templates overriding the built-in prompts
+	PromptsDir string `json:"prompts_dir,omitempty"`
+	// Feeds are the URLs of the RSS and Atom feeds the watch command polls
+	Feeds []string `json:"feeds,omitempty"`
+	// Sinks are the destinations each result is delivered to
+	Sinks []Sink `json:"sinks,omitempty"`
+	// Sources records where each setting came from: "default", the profile or an
+	// environment variable as resolved by Load, or "flag" once set with SetFlag
+	Sources map[string]string `json:"sources,omitempty"`
 }
 
-// New creates a new Config instance
+// New creates a new Config instance with the logging settings read from the environment
+// and the defaults for the rest. Load also applies the config file and NOSTRADAMUS_* variables.
 func New() *Config {
 	return &Config{
-		Debug: os.Getenv("DEBUG") == "1",
+		Debug:       os.Getenv("DEBUG") == "1",
+		LogLevel:    os.Getenv("LOG_LEVEL"),
+		LogFormat:   os.Getenv("LOG_FORMAT"),
+		LogContent:  os.Getenv("LOG_CONTENT"),
+		Secrets:     apiKeys(),
+		MaxAttempts: 10,
+		RetryDelay:  Duration(time.Second),
+		Format:      "json",
 	}
 }
 
+// apiKeys returns the values of the *_API_KEY environment variables
+func apiKeys() []string {
+	var keys []string
+	for _, env := range os.Environ() {
+		name, value, _ := strings.Cut(env, "=")
+		if strings.HasSuffix(name, "_API_KEY") && value != "" {
+			keys = append(keys, value)
+		}
+	}
+	return keys
+}
+
 // RetryDelay defines the waiting period between API call attempts.
 var RetryDelay = 1 * time.Second
+
+// MaxAttempts is the number of times a pipeline stage is attempted before giving up.
+var MaxAttempts = 10
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend is an LLM backend of a profile, called through an OpenAI-compatible chat
+// completions endpoint
+type Backend struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	URL      string `json:"url"`
+	// APIKeyEnv names the environment variable holding the API key; empty means
+	// PROVIDER_API_KEY
+	APIKeyEnv string `json:"api_key_env,omitempty"`
+	// KnowledgeCutoff is the end of the model's training data; empty uses the cutoff of
+	// known models
+	KnowledgeCutoff *Date `json:"knowledge_cutoff,omitempty"`
+	// RateLimit caps the calls made to the backend's model by every command; nil is
+	// unlimited
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+}
+
+// ParseBackend parses a backend given as provider/model@url
+func ParseBackend(value string) (Backend, error) {
+	name, url, ok := strings.Cut(value, "@")
+	if !ok || url == "" {
+		return Backend{}, fmt.Errorf("backend %q must be provider/model@url", value)
+	}
+	provider, model, ok := strings.Cut(name, "/")
+	if !ok || provider == "" || model == "" {
+		return Backend{}, fmt.Errorf("backend %q must be provider/model@url", value)
+	}
+	return Backend{Provider: provider, Model: model, URL: url}, nil
+}
+
+// RateLimit caps the calls made to a backend. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int `json:"rpm,omitempty"`
+	TokensPerMinute   int `json:"tpm,omitempty"`
+	// MaxConcurrent is the largest number of calls in flight at once
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+}
+
+// Sink is a destination results are delivered to after each run
+type Sink struct {
+	// Type is "webhook" for the result as JSON or "slack" for a Slack message
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+	// URLEnv names the environment variable holding the URL instead, for webhook URLs
+	// that embed a token such as Slack's
+	URLEnv string `json:"url_env,omitempty"`
+	// SecretEnv names the environment variable holding the secret deliveries are signed
+	// with; empty leaves them unsigned
+	SecretEnv string `json:"secret_env,omitempty"`
+	// Retry is the retry policy of deliveries; empty means 3 attempts a second apart
+	Retry Retry `json:"retry,omitempty"`
+}
+
+// Duration is a time.Duration written in JSON as a string such as "1s" or "500ms"
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Date is a day written in JSON as a YYYY-MM-DD string
+type Date time.Time
+
+// MarshalJSON writes the date as a YYYY-MM-DD string, empty for the zero date
+func (d Date) MarshalJSON() ([]byte, error) {
+	if time.Time(d).IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(time.Time(d).Format("2006-01-02"))
+}
+
+// UnmarshalJSON parses a YYYY-MM-DD string; an empty string is the zero date, meaning unset
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return err
+	}
+	*d = Date(parsed)
+	return nil
+}
+
+// Retry is the retry policy of the pipeline stages
+type Retry struct {
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+	Delay       Duration `json:"delay,omitempty"`
+}
+
+// Profile is a named set of settings in the config file. Empty fields keep the defaults.
+type Profile struct {
+	// Backends are called in priority order, failing over to the next one
+	Backends []Backend `json:"backends,omitempty"`
+	Retry    Retry     `json:"retry,omitempty"`
+	Format   string    `json:"format,omitempty"`
+	// StoragePath is the directory results are saved to
+	StoragePath string `json:"storage_path,omitempty"`
+	// PromptsDir holds templates overriding the built-in prompts
+	PromptsDir string `json:"prompts_dir,omitempty"`
+	// Feeds are the URLs of the RSS and Atom feeds the watch command polls
+	Feeds []string `json:"feeds,omitempty"`
+	// Sinks are the destinations each result is delivered to
+	Sinks []Sink `json:"sinks,omitempty"`
+}
+
+// File is the config file: named profiles and the one used when none is selected
+type File struct {
+	DefaultProfile string             `json:"default_profile,omitempty"`
+	Profiles       map[string]Profile `json:"profiles"`
+}
+
+// Load resolves the settings from the defaults, the config file and the environment, in
+// increasing order of precedence. The profile is the one given, else NOSTRADAMUS_PROFILE,
+// else the file's default profile; naming a profile the file lacks is an error.
+func Load(profile string) (*Config, error) {
+	c := New()
+	c.Sources = map[string]string{}
+	for _, key := range settingKeys {
+		c.Sources[key] = "default"
+	}
+
+	path, err := FindFile()
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		profile = os.Getenv("NOSTRADAMUS_PROFILE")
+	}
+	if path != "" {
+		file, err := ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file %s: %w", path, err)
+		}
+		c.Path = path
+		if profile == "" {
+			profile = file.DefaultProfile
+		}
+		if profile != "" {
+			p, ok := file.Profiles[profile]
+			if !ok {
+				return nil, fmt.Errorf("profile %q not found in %s, available: %s", profile, path, strings.Join(file.profileNames(), ", "))
+			}
+			c.Profile = profile
+			c.applyProfile(p, "profile "+profile)
+		}
+	} else if profile != "" {
+		return nil, fmt.Errorf("profile %q requested but no config file found", profile)
+	}
+
+	if err := c.applyEnv(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// settingKeys are the settings Load tracks the source of
+var settingKeys = []string{"backends", "max_attempts", "retry_delay", "format", "storage_path", "prompts_dir", "feeds", "sinks"}
+
+// applyProfile overrides the settings with the non-empty fields of p
+func (c *Config) applyProfile(p Profile, source string) {
+	if len(p.Backends) > 0 {
+		c.Backends = p.Backends
+		c.Sources["backends"] = source
+	}
+	if p.Retry.MaxAttempts > 0 {
+		c.MaxAttempts = p.Retry.MaxAttempts
+		c.Sources["max_attempts"] = source
+	}
+	if p.Retry.Delay > 0 {
+		c.RetryDelay = p.Retry.Delay
+		c.Sources["retry_delay"] = source
+	}
+	c.setString("format", &c.Format, p.Format, source)
+	c.setString("storage_path", &c.StoragePath, p.StoragePath, source)
+	c.setString("prompts_dir", &c.PromptsDir, p.PromptsDir, source)
+	if len(p.Feeds) > 0 {
+		c.Feeds = p.Feeds
+		c.Sources["feeds"] = source
+	}
+	if len(p.Sinks) > 0 {
+		c.Sinks = p.Sinks
+		c.Sources["sinks"] = source
+	}
+}
+
+// applyEnv overrides the settings with the NOSTRADAMUS_* environment variables that are set
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("NOSTRADAMUS_BACKENDS"); v != "" {
+		var backends []Backend
+		for _, spec := range strings.Split(v, ",") {
+			b, err := ParseBackend(strings.TrimSpace(spec))
+			if err != nil {
+				return fmt.Errorf("invalid NOSTRADAMUS_BACKENDS: %w", err)
+			}
+			backends = append(backends, b)
+		}
+		c.Backends = backends
+		c.Sources["backends"] = "env NOSTRADAMUS_BACKENDS"
+	}
+	if v := os.Getenv("NOSTRADAMUS_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid NOSTRADAMUS_MAX_ATTEMPTS %q", v)
+		}
+		c.MaxAttempts = n
+		c.Sources["max_attempts"] = "env NOSTRADAMUS_MAX_ATTEMPTS"
+	}
+	if v := os.Getenv("NOSTRADAMUS_RETRY_DELAY"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid NOSTRADAMUS_RETRY_DELAY %q: %w", v, err)
+		}
+		c.RetryDelay = Duration(d)
+		c.Sources["retry_delay"] = "env NOSTRADAMUS_RETRY_DELAY"
+	}
+	c.setString("format", &c.Format, os.Getenv("NOSTRADAMUS_FORMAT"), "env NOSTRADAMUS_FORMAT")
+	c.setString("storage_path", &c.StoragePath, os.Getenv("NOSTRADAMUS_STORAGE_PATH"), "env NOSTRADAMUS_STORAGE_PATH")
+	c.setString("prompts_dir", &c.PromptsDir, os.Getenv("NOSTRADAMUS_PROMPTS_DIR"), "env NOSTRADAMUS_PROMPTS_DIR")
+	return nil
+}
+
+// SetFlag records that the setting key was given by a command-line flag, which takes
+// precedence over the environment, the profile and the defaults
+func (c *Config) SetFlag(key string) {
+	if c.Sources == nil {
+		c.Sources = map[string]string{}
+	}
+	c.Sources[key] = "flag"
+}
+
+// setString sets *field to value from source unless value is empty
+func (c *Config) setString(key string, field *string, value, source string) {
+	if value == "" {
+		return
+	}
+	*field = value
+	c.Sources[key] = source
+}
+
+// profileNames returns the names of the profiles in f, sorted
+func (f *File) profileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadFile parses the config file at path
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FindFile returns the path of the config file: NOSTRADAMUS_CONFIG when set, else the
+// first nostradamus/config.json found in XDG_CONFIG_HOME (default ~/.config) and then
+// XDG_CONFIG_DIRS (default /etc/xdg). It returns an empty path when there is none.
+func FindFile() (string, error) {
+	if path := os.Getenv("NOSTRADAMUS_CONFIG"); path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("config file from NOSTRADAMUS_CONFIG: %w", err)
+		}
+		return path, nil
+	}
+	for _, dir := range configDirs() {
+		path := filepath.Join(dir, "nostradamus", "config.json")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// configDirs returns the XDG config directories in order of preference
+func configDirs() []string {
+	var dirs []string
+	if home := os.Getenv("XDG_CONFIG_HOME"); home != "" {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	systemDirs := os.Getenv("XDG_CONFIG_DIRS")
+	if systemDirs == "" {
+		systemDirs = "/etc/xdg"
+	}
+	for _, dir := range filepath.SplitList(systemDirs) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
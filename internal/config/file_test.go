@@ -0,0 +1,163 @@
+package config_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/config"
+)
+
+// isolate clears the environment Load reads until t ends, so that neither the user's
+// config file nor their NOSTRADAMUS_* variables leak into the test
+func isolate(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir())
+	for _, name := range []string{"CONFIG", "PROFILE", "BACKENDS", "MAX_ATTEMPTS", "RETRY_DELAY", "FORMAT", "STORAGE_PATH", "PROMPTS_DIR"} {
+		t.Setenv("NOSTRADAMUS_"+name, "")
+	}
+}
+
+// writeConfig writes contents as dir/nostradamus/config.json and returns its path
+func writeConfig(t *testing.T, dir, contents string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "nostradamus"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "nostradamus", "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const profiles = `{
+  "default_profile": "dev",
+  "profiles": {
+    "dev": {"format": "pretty", "retry": {"max_attempts": 3, "delay": "10ms"}},
+    "local-llm": {
+      "backends": [{"provider": "ollama", "model": "llama3", "url": "http://localhost:11434/v1/chat/completions", "api_key_env": "LOCAL_KEY"}],
+      "storage_path": "/tmp/nostradamus-runs"
+    }
+  }
+}`
+
+func TestLoadPrecedence(t *testing.T) {
+	isolate(t)
+	home := t.TempDir()
+	writeConfig(t, home, profiles)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Profile != "dev" || cfg.Format != "pretty" || cfg.MaxAttempts != 3 || time.Duration(cfg.RetryDelay) != 10*time.Millisecond {
+		t.Errorf("Expected the default dev profile applied, got %+v", cfg)
+	}
+	if cfg.Sources["format"] != "profile dev" || cfg.Sources["storage_path"] != "default" {
+		t.Errorf("Unexpected sources: %v", cfg.Sources)
+	}
+
+	// The environment overrides the file
+	t.Setenv("NOSTRADAMUS_FORMAT", "markdown")
+	t.Setenv("NOSTRADAMUS_PROFILE", "local-llm")
+	cfg, err = config.Load("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Profile != "local-llm" || cfg.Format != "markdown" || cfg.Sources["format"] != "env NOSTRADAMUS_FORMAT" || cfg.MaxAttempts != 10 {
+		t.Errorf("Expected env to select local-llm and override the format, got %+v", cfg)
+	}
+	if len(cfg.Backends) != 1 || cfg.Backends[0].APIKeyEnv != "LOCAL_KEY" || cfg.StoragePath != "/tmp/nostradamus-runs" {
+		t.Errorf("Expected the local-llm backends and storage path, got %+v", cfg)
+	}
+
+	// NOSTRADAMUS_BACKENDS replaces the profile's backends, and flags are recorded over both
+	t.Setenv("NOSTRADAMUS_BACKENDS", "groq/llama3@https://api.groq.com/openai/v1/chat/completions, openai/o1-mini@https://api.openai.com/v1/chat/completions")
+	cfg, err = config.Load("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.Backends) != 2 || cfg.Backends[1].Model != "o1-mini" || cfg.Sources["backends"] != "env NOSTRADAMUS_BACKENDS" {
+		t.Errorf("Expected the backends from the environment, got %+v from %s", cfg.Backends, cfg.Sources["backends"])
+	}
+	cfg.SetFlag("format")
+	if cfg.Sources["format"] != "flag" {
+		t.Errorf("Expected the format recorded as set by a flag, got %v", cfg.Sources)
+	}
+	t.Setenv("NOSTRADAMUS_BACKENDS", "groq")
+	if _, err := config.Load(""); err == nil || !strings.Contains(err.Error(), "NOSTRADAMUS_BACKENDS") {
+		t.Errorf("Expected invalid backends in the environment rejected, got %v", err)
+	}
+	t.Setenv("NOSTRADAMUS_BACKENDS", "")
+
+	// An explicit profile wins over the environment, and unknown profiles are errors
+	if cfg, err = config.Load("dev"); err != nil || cfg.Profile != "dev" {
+		t.Errorf("Expected the dev profile, got %v, %v", cfg, err)
+	}
+	if _, err := config.Load("prod"); err == nil || !strings.Contains(err.Error(), "available: dev, local-llm") {
+		t.Errorf("Expected unknown profile error, got: %v", err)
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	isolate(t)
+	cfg, err := config.Load("")
+	if err != nil || cfg.Path != "" || cfg.Profile != "" {
+		t.Errorf("Expected the defaults without a config file, got %+v, %v", cfg, err)
+	}
+	if _, err := config.Load("dev"); err == nil || !strings.Contains(err.Error(), "no config file found") {
+		t.Errorf("Expected a requested profile without a config file rejected, got: %v", err)
+	}
+}
+
+func TestFindFile(t *testing.T) {
+	isolate(t)
+	home, system := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir()+string(os.PathListSeparator)+system)
+
+	systemPath := writeConfig(t, system, profiles)
+	if path, err := config.FindFile(); err != nil || path != systemPath {
+		t.Errorf("Expected the system config file, got %q, %v", path, err)
+	}
+	homePath := writeConfig(t, home, profiles)
+	if path, err := config.FindFile(); err != nil || path != homePath {
+		t.Errorf("Expected the user's config file preferred, got %q, %v", path, err)
+	}
+
+	t.Setenv("NOSTRADAMUS_CONFIG", systemPath)
+	if path, err := config.FindFile(); err != nil || path != systemPath {
+		t.Errorf("Expected NOSTRADAMUS_CONFIG preferred, got %q, %v", path, err)
+	}
+	t.Setenv("NOSTRADAMUS_CONFIG", filepath.Join(home, "missing.json"))
+	if _, err := config.FindFile(); err == nil || !strings.Contains(err.Error(), "NOSTRADAMUS_CONFIG") {
+		t.Errorf("Expected a missing NOSTRADAMUS_CONFIG file rejected, got: %v", err)
+	}
+}
+
+func TestKnowledgeCutoffDate(t *testing.T) {
+	var file config.File
+	if err := json.Unmarshal([]byte(`{"profiles": {"prod": {"backends": [
+		{"provider": "groq", "model": "llama3", "url": "https://api.groq.com/openai/v1/chat/completions", "knowledge_cutoff": "2023-12-01"},
+		{"provider": "ollama", "model": "llama3", "url": "http://localhost:11434/v1/chat/completions", "knowledge_cutoff": ""}
+	]}}}`), &file); err != nil {
+		t.Fatalf("Expected an empty knowledge cutoff to parse, got: %v", err)
+	}
+	backends := file.Profiles["prod"].Backends
+	if !time.Time(*backends[0].KnowledgeCutoff).Equal(time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)) || !time.Time(*backends[1].KnowledgeCutoff).IsZero() {
+		t.Errorf("Expected the set cutoff parsed and the empty one left unset, got %+v", backends)
+	}
+	encoded, err := json.Marshal(backends[0].KnowledgeCutoff)
+	if err != nil || string(encoded) != `"2023-12-01"` {
+		t.Errorf("Expected the cutoff written as YYYY-MM-DD, got %s, %v", encoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"profiles": {"prod": {"backends": [{"provider": "groq", "model": "llama3", "url": "x", "knowledge_cutoff": "December 2023"}]}}}`), &file); err == nil {
+		t.Error("Expected an invalid knowledge cutoff to be rejected")
+	}
+}
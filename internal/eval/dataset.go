@@ -0,0 +1,63 @@
+package eval
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+//go:embed events.json
+var bundledEvents []byte
+
+// Event is a historical event with the market reaction observed after it
+type Event struct {
+	ID    string `json:"id"`
+	Date  string `json:"date"`
+	Event string `json:"event"`
+	// Horizon is the window the outcomes were observed over, e.g. "1 month"
+	Horizon string `json:"horizon"`
+	// Summary describes what happened, for the LLM judge
+	Summary  string            `json:"summary"`
+	Outcomes []models.Reaction `json:"outcomes"`
+}
+
+// Dataset returns the bundled golden dataset of historical events
+func Dataset() ([]Event, error) {
+	return LoadDataset(strings.NewReader(string(bundledEvents)))
+}
+
+// LoadDataset decodes a JSON array of events and checks each is complete, dated as
+// YYYY-MM-DD and has outcomes tagged with the bundled sectors and directions
+func LoadDataset(r io.Reader) ([]Event, error) {
+	var events []Event
+	if err := json.NewDecoder(r).Decode(&events); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for i, e := range events {
+		if e.ID == "" || e.Event == "" || len(e.Outcomes) == 0 {
+			return nil, fmt.Errorf("event %d needs an id, an event and outcomes", i)
+		}
+		if seen[e.ID] {
+			return nil, fmt.Errorf("duplicate event id %q", e.ID)
+		}
+		seen[e.ID] = true
+		if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+			return nil, fmt.Errorf("event %s: invalid date %q", e.ID, e.Date)
+		}
+		if _, err := models.ParseTimeframe(e.Horizon); err != nil {
+			return nil, fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		for _, o := range e.Outcomes {
+			if err := o.Validate(); err != nil {
+				return nil, fmt.Errorf("event %s: %w", e.ID, err)
+			}
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,32 @@
+package eval_test
+
+import (
+	"strings"
+	"testing"
+
+	"nostradamus/internal/eval"
+)
+
+func TestBundledDataset(t *testing.T) {
+	events, err := eval.Dataset()
+	if err != nil {
+		t.Fatalf("Bundled dataset is invalid: %v", err)
+	}
+	if len(events) < 5 {
+		t.Errorf("Expected a handful of bundled events, got %d", len(events))
+	}
+}
+
+func TestLoadDatasetErrors(t *testing.T) {
+	for name, dataset := range map[string]string{
+		"unknown sector":  `[{"id": "x", "date": "2020-01-01", "event": "E", "horizon": "1 week", "outcomes": [{"sector": "Crypto", "direction": "bullish"}]}]`,
+		"no outcomes":     `[{"id": "x", "date": "2020-01-01", "event": "E", "horizon": "1 week"}]`,
+		"duplicate id":    `[{"id": "x", "date": "2020-01-01", "event": "E", "horizon": "1 week", "outcomes": [{"sector": "Energy", "direction": "bullish"}]}, {"id": "x", "date": "2020-01-01", "event": "E", "horizon": "1 week", "outcomes": [{"sector": "Energy", "direction": "bullish"}]}]`,
+		"invalid date":    `[{"id": "x", "date": "01/01/2020", "event": "E", "horizon": "1 week", "outcomes": [{"sector": "Energy", "direction": "bullish"}]}]`,
+		"invalid horizon": `[{"id": "x", "date": "2020-01-01", "event": "E", "horizon": "soon", "outcomes": [{"sector": "Energy", "direction": "bullish"}]}]`,
+	} {
+		if _, err := eval.LoadDataset(strings.NewReader(dataset)); err == nil {
+			t.Errorf("Expected a dataset with %s to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// EventResult is the outcome of running the pipeline on one event
+type EventResult struct {
+	ID string `json:"id"`
+	// Error is set when the run failed
+	Error       string `json:"error,omitempty"`
+	Backend     string `json:"backend,omitempty"`
+	Predictions int    `json:"predictions"`
+	Scores      Scores `json:"scores"`
+	// JudgeRationale explains the judge's score, when one was asked for
+	JudgeRationale string `json:"judge_rationale,omitempty"`
+}
+
+// Report is the result of an evaluation, comparable with reports over the same events
+type Report struct {
+	CreatedAt time.Time     `json:"created_at"`
+	Profile   string        `json:"profile,omitempty"`
+	Events    []EventResult `json:"events"`
+	// Summary holds the mean of every score over the events; the judge mean only covers
+	// the events that were judged
+	Summary Scores `json:"summary"`
+}
+
+// NewReport summarises the results of an evaluation
+func NewReport(results []EventResult) Report {
+	r := Report{CreatedAt: time.Now().UTC(), Events: results}
+	if len(results) == 0 {
+		return r
+	}
+	var judged int
+	var judgeTotal float64
+	for _, e := range results {
+		r.Summary.Valid += e.Scores.Valid
+		r.Summary.SectorRecall += e.Scores.SectorRecall
+		r.Summary.DirectionAccuracy += e.Scores.DirectionAccuracy
+		r.Summary.Brier += e.Scores.Brier
+		r.Summary.TagCoverage += e.Scores.TagCoverage
+		r.Summary.Overall += e.Scores.Overall
+		if e.Scores.Judge != nil {
+			judged++
+			judgeTotal += *e.Scores.Judge
+		}
+	}
+	n := float64(len(results))
+	r.Summary.Valid /= n
+	r.Summary.SectorRecall /= n
+	r.Summary.DirectionAccuracy /= n
+	r.Summary.Brier /= n
+	r.Summary.TagCoverage /= n
+	r.Summary.Overall /= n
+	if judged > 0 {
+		judge := judgeTotal / float64(judged)
+		r.Summary.Judge = &judge
+	}
+	return r
+}
+
+// ReadReport decodes a report written as JSON
+func ReadReport(r io.Reader) (Report, error) {
+	var report Report
+	err := json.NewDecoder(r).Decode(&report)
+	return report, err
+}
+
+// Delta compares a summary metric between a baseline and a current report
+type Delta struct {
+	Metric    string
+	Baseline  float64
+	Current   float64
+	Regressed bool
+}
+
+// Compare returns the change of every summary metric from baseline to current. A metric
+// regressed when it got worse by more than tolerance. Reports over different events are
+// not comparable.
+func Compare(baseline, current Report, tolerance float64) ([]Delta, error) {
+	if !sameEvents(baseline, current) {
+		return nil, fmt.Errorf("reports cover different events: %s vs %s", eventIDs(baseline), eventIDs(current))
+	}
+	type metric struct {
+		name          string
+		baseline      float64
+		current       float64
+		lowerIsBetter bool
+	}
+	b, c := baseline.Summary, current.Summary
+	metrics := []metric{
+		{"valid", b.Valid, c.Valid, false},
+		{"sector_recall", b.SectorRecall, c.SectorRecall, false},
+		{"direction_accuracy", b.DirectionAccuracy, c.DirectionAccuracy, false},
+		{"brier", b.Brier, c.Brier, true},
+		{"tag_coverage", b.TagCoverage, c.TagCoverage, false},
+	}
+	if b.Judge != nil && c.Judge != nil {
+		metrics = append(metrics, metric{"judge", *b.Judge, *c.Judge, false})
+	}
+	metrics = append(metrics, metric{"overall", b.Overall, c.Overall, false})
+
+	deltas := make([]Delta, len(metrics))
+	for i, m := range metrics {
+		worsening := m.baseline - m.current
+		if m.lowerIsBetter {
+			worsening = -worsening
+		}
+		deltas[i] = Delta{Metric: m.name, Baseline: m.baseline, Current: m.current, Regressed: worsening > tolerance}
+	}
+	return deltas, nil
+}
+
+// sameEvents reports whether a and b cover the same event ids
+func sameEvents(a, b Report) bool {
+	return eventIDs(a) == eventIDs(b)
+}
+
+// eventIDs lists the event ids of r, sorted and comma separated
+func eventIDs(r Report) string {
+	ids := make([]string, len(r.Events))
+	for i, e := range r.Events {
+		ids[i] = e.ID
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+// WriteText writes the per-event scores and the summary of r as a table
+func WriteText(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "EVENT\tVALID\tRECALL\tACCURACY\tBRIER\tTAGS\tJUDGE\tOVERALL")
+	for _, e := range r.Events {
+		writeRow(tw, e.ID, e.Scores)
+	}
+	writeRow(tw, "MEAN", r.Summary)
+	return tw.Flush()
+}
+
+func writeRow(w io.Writer, name string, s Scores) {
+	judge := "-"
+	if s.Judge != nil {
+		judge = fmt.Sprintf("%.2f", *s.Judge)
+	}
+	fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%s\t%.2f\n",
+		name, s.Valid, s.SectorRecall, s.DirectionAccuracy, s.Brier, s.TagCoverage, judge, s.Overall)
+}
+
+// WriteComparison writes deltas as a table, marking regressions
+func WriteComparison(w io.Writer, deltas []Delta) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tBASELINE\tCURRENT\tDELTA\t")
+	for _, d := range deltas {
+		mark := ""
+		if d.Regressed {
+			mark = "REGRESSED"
+		}
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%+.3f\t%s\n", d.Metric, d.Baseline, d.Current, d.Current-d.Baseline, mark)
+	}
+	return tw.Flush()
+}
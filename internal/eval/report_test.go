@@ -0,0 +1,62 @@
+package eval_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/eval"
+)
+
+func TestReportSummary(t *testing.T) {
+	judge := 0.9
+	report := eval.NewReport([]eval.EventResult{
+		{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 1, Brier: 0.1, Overall: 0.8, Judge: &judge}},
+		{ID: "b", Error: "timed out", Scores: eval.Scores{Brier: 1}},
+	})
+	s := report.Summary
+	if s.Valid != 0.5 || s.SectorRecall != 0.5 || s.Brier != 0.55 || s.Overall != 0.4 || s.Judge == nil || *s.Judge != 0.9 {
+		t.Errorf("Expected means over both events and the judge over the judged one, got %+v", s)
+	}
+
+	var buf bytes.Buffer
+	if err := eval.WriteText(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "MEAN") || !strings.Contains(buf.String(), "0.90") {
+		t.Errorf("Unexpected text report: %s", buf.String())
+	}
+
+	read, err := eval.ReadReport(strings.NewReader(`{"events": [{"id": "a", "predictions": 1, "scores": {"overall": 0.8}}], "summary": {"overall": 0.8}}`))
+	if err != nil || len(read.Events) != 1 || read.Summary.Overall != 0.8 {
+		t.Errorf("Expected the report decoded, got %+v, %v", read, err)
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	baseline := eval.NewReport([]eval.EventResult{{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 0.8, DirectionAccuracy: 0.8, Brier: 0.1, TagCoverage: 1, Overall: 0.9}}})
+	current := eval.NewReport([]eval.EventResult{{ID: "a", Scores: eval.Scores{Valid: 1, SectorRecall: 0.79, DirectionAccuracy: 0.8, Brier: 0.3, TagCoverage: 1, Overall: 0.86}}})
+
+	deltas, err := eval.Compare(baseline, current, 0.02)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var regressed []string
+	for _, d := range deltas {
+		if d.Regressed {
+			regressed = append(regressed, d.Metric)
+		}
+	}
+	if strings.Join(regressed, ",") != "brier,overall" {
+		t.Errorf("Expected a higher brier and a lower overall regressed beyond the tolerance, got %v", regressed)
+	}
+
+	var buf bytes.Buffer
+	if err := eval.WriteComparison(&buf, deltas); err != nil || strings.Count(buf.String(), "REGRESSED") != 2 {
+		t.Errorf("Expected both regressions marked, got:\n%s", buf.String())
+	}
+
+	if _, err := eval.Compare(baseline, eval.NewReport([]eval.EventResult{{ID: "b"}}), 0.02); err == nil {
+		t.Errorf("Expected reports over different events to be incomparable")
+	}
+}
@@ -0,0 +1,110 @@
+package eval
+
+import (
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// Scores are the metrics of a run, or their mean over several runs. All lie in [0, 1] and
+// all but Brier are better when higher.
+type Scores struct {
+	// Valid is 1 when the run produced valid critiqued predictions
+	Valid float64 `json:"valid"`
+	// SectorRecall is the share of observed sector reactions that some prediction anticipated
+	// with the right direction
+	SectorRecall float64 `json:"sector_recall"`
+	// DirectionAccuracy is the share of resolvable predictions whose direction matched
+	DirectionAccuracy float64 `json:"direction_accuracy"`
+	// Brier is the mean squared error of the overall probability of resolvable predictions,
+	// the critic's confidence unless it is given parents
+	Brier float64 `json:"brier"`
+	// TagCoverage is the share of predictions tagged with sectors and a direction
+	TagCoverage float64 `json:"tag_coverage"`
+	// Judge is the LLM judge's score, when one was asked for
+	Judge *float64 `json:"judge,omitempty"`
+	// Overall is the mean of the other scores, with Brier counted as 1 - Brier
+	Overall float64 `json:"overall"`
+}
+
+// Score rates critiqued predictions against the observed outcomes of e. A prediction is
+// resolvable when it is tagged with a sector that has an observed outcome, and correct when
+// its direction matches the outcome of one of those sectors. A nil result scores as a
+// failed run: zero on every metric and the worst Brier score.
+func Score(e Event, result *models.CritiquedResponse) Scores {
+	if result == nil || len(result.Predictions) == 0 {
+		s := Scores{Brier: 1}
+		s.Overall = overall(s)
+		return s
+	}
+
+	observed := make(map[string]string, len(e.Outcomes))
+	for _, o := range e.Outcomes {
+		observed[strings.ToLower(o.Sector)] = strings.ToLower(o.Direction)
+	}
+	anticipated := make(map[string]bool)
+	var tagged, resolved, correct int
+	var squaredError float64
+	for _, p := range result.Predictions {
+		direction := strings.ToLower(p.Direction)
+		if len(p.Sectors) > 0 && direction != "" {
+			tagged++
+		}
+		known, hit := false, false
+		for _, sector := range p.Sectors {
+			outcome, ok := observed[strings.ToLower(sector)]
+			if !ok {
+				continue
+			}
+			known = true
+			if outcome == direction {
+				hit = true
+				anticipated[strings.ToLower(sector)] = true
+			}
+		}
+		if !known {
+			continue
+		}
+		resolved++
+		actual := 0.0
+		if hit {
+			correct++
+			actual = 1
+		}
+		forecast := p.Likelihood()
+		squaredError += (forecast - actual) * (forecast - actual)
+	}
+
+	s := Scores{
+		Valid:        1,
+		SectorRecall: float64(len(anticipated)) / float64(len(observed)),
+		TagCoverage:  float64(tagged) / float64(len(result.Predictions)),
+		Brier:        1,
+	}
+	if resolved > 0 {
+		s.DirectionAccuracy = float64(correct) / float64(resolved)
+		s.Brier = squaredError / float64(resolved)
+	}
+	s.Overall = overall(s)
+	return s
+}
+
+// overall averages the scores of s into a single higher-is-better score
+func overall(s Scores) float64 {
+	values := []float64{s.Valid, s.SectorRecall, s.DirectionAccuracy, 1 - s.Brier, s.TagCoverage}
+	if s.Judge != nil {
+		values = append(values, *s.Judge)
+	}
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+// WithJudge returns s with the judge's score added
+func (s Scores) WithJudge(score float64) Scores {
+	s.Judge = &score
+	s.Overall = overall(s)
+	return s
+}
@@ -0,0 +1,46 @@
+package eval_test
+
+import (
+	"math"
+	"testing"
+
+	"nostradamus/internal/eval"
+	"nostradamus/internal/models"
+)
+
+func TestScoreAgainstOutcomes(t *testing.T) {
+	event := eval.Event{ID: "e", Outcomes: []models.Reaction{{Sector: "Energy", Direction: "bullish"}, {Sector: "Financials", Direction: "bearish"}}}
+	result := &models.CritiquedResponse{Predictions: []models.CritiquedPrediction{
+		{Sectors: []string{"Energy"}, Direction: "bullish", Confidence: 0.8},
+		{Sectors: []string{"Financials"}, Direction: "bullish", Confidence: 0.6},
+		{Sectors: []string{"Health Care"}, Direction: "bearish", Confidence: 0.5},
+		{Confidence: 0.9},
+	}}
+	s := eval.Score(event, result)
+	approx := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if s.Valid != 1 || !approx(s.SectorRecall, 0.5) || !approx(s.DirectionAccuracy, 0.5) || !approx(s.TagCoverage, 0.75) {
+		t.Errorf("Unexpected scores: %+v", s)
+	}
+	// (0.8-1)^2 and (0.6-0)^2 over the two resolvable predictions
+	if !approx(s.Brier, (0.04+0.36)/2) {
+		t.Errorf("Expected Brier 0.2, got %v", s.Brier)
+	}
+	if !approx(s.Overall, (1+0.5+0.5+0.8+0.75)/5) || !approx(s.WithJudge(1).Overall, (1+0.5+0.5+0.8+0.75+1)/6) {
+		t.Errorf("Unexpected overall score: %v", s.Overall)
+	}
+	if failed := eval.Score(event, nil); failed.Valid != 0 || failed.Brier != 1 || failed.Overall != 0 {
+		t.Errorf("Expected a failed run to score worst, got %+v", failed)
+	}
+
+	// A dependent prediction is scored on its overall probability, 0.8 * 0.5 = 0.4
+	graph := &models.CritiquedResponse{Predictions: []models.CritiquedPrediction{
+		{ID: "p1", Confidence: 0.5},
+		{ID: "p2", Parents: []string{"p1"}, Sectors: []string{"Energy"}, Direction: "bullish", Confidence: 0.8},
+	}}
+	if err := graph.PropagateConfidence(); err != nil {
+		t.Fatal(err)
+	}
+	if s := eval.Score(event, graph); !approx(s.Brier, 0.36) {
+		t.Errorf("Expected Brier 0.36 from the overall probability, got %v", s.Brier)
+	}
+}
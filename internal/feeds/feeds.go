@@ -0,0 +1,237 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Item is a news item of an RSS or Atom feed
+type Item struct {
+	// ID is the item's GUID or Atom ID, else its link, else its title
+	ID        string
+	Title     string
+	Summary   string
+	Link      string
+	Published time.Time
+}
+
+// Input returns the text predictions are made for: the headline followed by the summary
+func (i Item) Input() string {
+	if i.Summary == "" || i.Summary == i.Title {
+		return i.Title
+	}
+	return strings.TrimRight(i.Title, ".!? ") + ". " + i.Summary
+}
+
+// rssItem is an item of an RSS 0.9x, 1.0 or 2.0 feed
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Summary string `xml:"summary"`
+	Content string `xml:"content"`
+	Links   []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+// document is the root of an RSS or Atom feed. RSS 2.0 nests its items in a channel,
+// RSS 1.0 lists them at the root and Atom calls them entries.
+type document struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// Parse reads the items of an RSS or Atom feed, in feed order
+func Parse(r io.Reader) ([]Item, error) {
+	var doc document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	var items []Item
+	switch doc.XMLName.Local {
+	case "rss", "RDF":
+		for _, it := range append(doc.Channel.Items, doc.Items...) {
+			items = append(items, newItem(it.GUID, it.Title, it.Description, it.Link, firstNonEmpty(it.PubDate, it.Date)))
+		}
+	case "feed":
+		for _, e := range doc.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			items = append(items, newItem(e.ID, e.Title, firstNonEmpty(e.Summary, e.Content), link, firstNonEmpty(e.Published, e.Updated)))
+		}
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element %q", doc.XMLName.Local)
+	}
+	return items, nil
+}
+
+// newItem cleans the fields of a feed item and identifies it
+func newItem(id, title, summary, link, published string) Item {
+	it := Item{
+		Title:   plainText(title),
+		Summary: plainText(summary),
+		Link:    strings.TrimSpace(link),
+	}
+	it.ID = firstNonEmpty(strings.TrimSpace(id), it.Link, it.Title)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(published)); err == nil {
+			it.Published = t
+			break
+		}
+	}
+	return it
+}
+
+// tags matches HTML tags, which feeds often embed in summaries
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+// plainText strips the HTML tags and entities of s and collapses its whitespace
+func plainText(s string) string {
+	s = html.UnescapeString(tags.ReplaceAllString(s, " "))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Fetch downloads and parses the feed at url. client should have a Timeout, so a stalled
+// feed cannot block the caller.
+func Fetch(client *http.Client, url string) ([]Item, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: status %s", url, resp.Status)
+	}
+	return Parse(resp.Body)
+}
+
+// Seen records the IDs of the items already processed and the failed runs of the others,
+// in a JSON file when it has a path
+type Seen struct {
+	path     string
+	ids      map[string]bool
+	failures map[string]int
+}
+
+// seenState is the JSON form of Seen
+type seenState struct {
+	Seen     []string       `json:"seen"`
+	Failures map[string]int `json:"failures,omitempty"`
+}
+
+// LoadSeen reads the IDs recorded at path. A missing file starts empty, and an empty path
+// keeps the IDs in memory only. Files holding a plain array of IDs are read too.
+func LoadSeen(path string) (*Seen, error) {
+	s := &Seen{path: path, ids: make(map[string]bool), failures: make(map[string]int)}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state seenState
+	if err := json.Unmarshal(data, &state.Seen); err != nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("reading seen items %s: %w", path, err)
+		}
+	}
+	for _, id := range state.Seen {
+		s.ids[id] = true
+	}
+	for id, n := range state.Failures {
+		s.failures[id] = n
+	}
+	return s, nil
+}
+
+// Has reports whether the item with id was processed
+func (s *Seen) Has(id string) bool {
+	return s.ids[id]
+}
+
+// Add records the item with id as processed, forgetting its failures, and saves the IDs
+func (s *Seen) Add(id string) error {
+	if s.ids[id] {
+		return nil
+	}
+	s.ids[id] = true
+	delete(s.failures, id)
+	return s.save()
+}
+
+// Fail records a failed run for the item with id, saves it and returns how many runs of
+// the item have failed
+func (s *Seen) Fail(id string) (int, error) {
+	s.failures[id]++
+	return s.failures[id], s.save()
+}
+
+// save writes the IDs and failures to the file, if there is one
+func (s *Seen) save() error {
+	if s.path == "" {
+		return nil
+	}
+	state := seenState{Seen: make([]string, 0, len(s.ids)), Failures: s.failures}
+	for id := range s.ids {
+		state.Seen = append(state.Seen, id)
+	}
+	sort.Strings(state.Seen)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
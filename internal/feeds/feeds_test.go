@@ -0,0 +1,119 @@
+package feeds_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/feeds"
+)
+
+func open(t *testing.T, name string) *os.File {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseFeeds(t *testing.T) {
+	items, err := feeds.Parse(open(t, "rss.xml"))
+	if err != nil {
+		t.Fatalf("Failed to parse RSS: %v", err)
+	}
+	if len(items) != 2 || items[0].ID != "rss-1" || items[0].Summary != "Producers cut 1.2m barrels a day." || items[0].Published.IsZero() {
+		t.Errorf("Unexpected RSS items %+v", items)
+	}
+	if items[1].ID != "https://news.example/fed" || items[1].Input() != "Fed holds rates" {
+		t.Errorf("Expected an item without a GUID identified by its link, got %+v", items[1])
+	}
+	if got := items[0].Input(); got != "OPEC+ announces surprise output cut. Producers cut 1.2m barrels a day." {
+		t.Errorf("Unexpected input %q", got)
+	}
+
+	items, err = feeds.Parse(open(t, "atom.xml"))
+	if err != nil {
+		t.Fatalf("Failed to parse Atom: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "urn:wire:1" || items[0].Link != "https://wire.example/chips" || items[0].Summary != "New rules cover advanced GPUs." {
+		t.Errorf("Unexpected Atom items %+v", items)
+	}
+
+	if _, err := feeds.Parse(strings.NewReader(`<html><body>Not a feed</body></html>`)); err == nil {
+		t.Error("Expected a non-feed document to be rejected")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/atom" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/atom.xml")
+	}))
+	defer server.Close()
+
+	items, err := feeds.Fetch(server.Client(), server.URL+"/atom")
+	if err != nil || len(items) != 1 {
+		t.Errorf("Expected the Atom feed fetched, got %+v: %v", items, err)
+	}
+	if _, err := feeds.Fetch(server.Client(), server.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected a missing feed to fail with its status, got %v", err)
+	}
+}
+
+func TestSeen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "seen.json")
+	seen, err := feeds.LoadSeen(path)
+	if err != nil {
+		t.Fatalf("Expected a missing file to start empty, got %v", err)
+	}
+	if err := seen.Add("a"); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := seen.Fail("b"); n != 1 || err != nil {
+		t.Fatalf("Expected the first failure counted, got %d: %v", n, err)
+	}
+
+	seen, err = feeds.LoadSeen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !seen.Has("a") || seen.Has("b") {
+		t.Error("Expected only the added item seen after a reload")
+	}
+	if n, _ := seen.Fail("b"); n != 2 {
+		t.Errorf("Expected failures counted across reloads, got %d", n)
+	}
+	seen.Add("b")
+	if n, _ := seen.Fail("b"); n != 1 {
+		t.Errorf("Expected adding an item to forget its failures, got %d", n)
+	}
+
+	legacy := filepath.Join(t.TempDir(), "legacy.json")
+	if err := os.WriteFile(legacy, []byte(`["x", "y"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	seen, err = feeds.LoadSeen(legacy)
+	if err != nil || !seen.Has("x") || !seen.Has("y") {
+		t.Errorf("Expected a plain array of IDs read, got %v", err)
+	}
+
+	if err := os.WriteFile(legacy, []byte(`{"seen": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := feeds.LoadSeen(legacy); err == nil {
+		t.Error("Expected a malformed file to be rejected")
+	}
+
+	seen, _ = feeds.LoadSeen("")
+	if err := seen.Add("a"); err != nil || !seen.Has("a") {
+		t.Errorf("Expected an in-memory set to record items, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// AnalogFinder finds the past market events most similar to an event
+type AnalogFinder interface {
+	Find(event string, before time.Time, k int) []models.Analog
+}
+
+// WithAnalogs gives the critic the k past events of f most similar to the input, with
+// the sector reactions observed after them, to anchor its confidences in precedent.
+// Only events before the as-of date are used.
+func WithAnalogs(f AnalogFinder, k int) Option {
+	return func(o *options) {
+		o.analogs = f
+		o.analogCount = k
+	}
+}
+
+// findAnalogs looks up the past events similar to the input, if an analog finder is set
+func (p *pipeline) findAnalogs() {
+	if p.opts.analogs == nil || p.opts.analogCount <= 0 {
+		return
+	}
+	stage := p.root.child("analogs", "stage", "analogs")
+	defer stage.span.End()
+	p.analogs = p.opts.analogs.Find(p.input, p.opts.asOf, p.opts.analogCount)
+	stage.span.SetAttribute("analogs", len(p.analogs))
+	stage.log.Info("Found historical analogs", "analogs", len(p.analogs))
+}
+
+// analogInstructions lists the historical analogs for the critic; it is empty when there
+// are none
+func analogInstructions(analogs []models.Analog) string {
+	if len(analogs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Anchor each confidence in precedent: the following past events resemble this one, with the sector reactions actually observed after them. Rate a prediction higher when comparable events led to the reaction it describes and lower when they did not. Historical analogs:")
+	for _, a := range analogs {
+		reactions := make([]string, len(a.Outcomes))
+		for i, o := range a.Outcomes {
+			reactions[i] = o.Sector + " " + o.Direction
+		}
+		fmt.Fprintf(&b, "\n- %s (%s). %s Sector reactions over %s: %s.", a.Event, a.Date, a.Summary, a.Horizon, strings.Join(reactions, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,40 @@
+package llm_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/analogs"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+)
+
+func TestAnalogsInCritiquePrompt(t *testing.T) {
+	var predictorPrompt, critiquePrompt string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			critiquePrompt = body
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Oil spikes", "impact": "Energy rallies", "confidence": 0.8, "critique": "As in 1990"}]}`
+		}
+		predictorPrompt = body
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Oil spikes", "impact": "Energy rallies"}]}`
+	})
+	dataset, err := analogs.Bundled()
+	if err != nil {
+		t.Fatalf("Failed to load bundled analogs: %v", err)
+	}
+	result, err := llm.GenerateCritiquedResponse("Iran closes the Strait of Hormuz, cutting Gulf oil supply", client, llm.WithAnalogs(dataset, 1))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(critiquePrompt, "Iraq invades Kuwait, threatening Gulf oil supply (1990-08-02)") || !strings.Contains(critiquePrompt, "Energy bullish, Industrials bearish, Consumer Discretionary bearish") {
+		t.Errorf("Expected the analog and its reactions in the critique prompt, got: %s", critiquePrompt)
+	}
+	if strings.Contains(predictorPrompt, "Iraq") {
+		t.Errorf("Expected analogs kept out of the predictor prompt, got: %s", predictorPrompt)
+	}
+	if len(result.Analogs) != 1 || result.Analogs[0].ID != "iraq-invades-kuwait" {
+		t.Errorf("Expected the analog listed in the output, got %+v", result.Analogs)
+	}
+}
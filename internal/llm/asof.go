@@ -0,0 +1,37 @@
+package llm
+
+import "time"
+
+// knowledgeCutoffs are the training data cutoffs of known models
+var knowledgeCutoffs = map[string]time.Time{
+	"o1-mini":     time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC),
+	"o1":          time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC),
+	"o3-mini":     time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC),
+	"gpt-4o":      time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC),
+	"gpt-4o-mini": time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC),
+	"gpt-4-turbo": time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// knowledgeCutoff returns the end of b's training data, if known
+func (b Backend) knowledgeCutoff() (time.Time, bool) {
+	if !b.KnowledgeCutoff.IsZero() {
+		return b.KnowledgeCutoff, true
+	}
+	cutoff, ok := knowledgeCutoffs[b.Model]
+	return cutoff, ok
+}
+
+// warnAfterCutoff warns about every backend of the run whose knowledge ends before the
+// as-of date, since it cannot know what happened in between
+func (p *pipeline) warnAfterCutoff() {
+	for _, b := range p.client.backends {
+		cutoff, ok := b.knowledgeCutoff()
+		if !ok || !p.opts.asOf.After(cutoff) {
+			continue
+		}
+		p.root.log.Warn("As-of date is after the model's knowledge cutoff, it knows nothing of what happened since",
+			"as_of", p.opts.asOf.Format("2006-01-02"),
+			"backend", b.Name(),
+			"knowledge_cutoff", cutoff.Format("2006-01-02"))
+	}
+}
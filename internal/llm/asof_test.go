@@ -0,0 +1,75 @@
+package llm_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+)
+
+func TestAsOfDateInPrompts(t *testing.T) {
+	var prompts []string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		prompts = append(prompts, body)
+		if llmtest.IsCritique(body) {
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "6 months", "description": "Event A", "impact": "Market volatility", "confidence": 0.5, "critique": "Plausible"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "6 months", "description": "Event A", "impact": "Market volatility"}]}`
+	})
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logs, nil))
+	asOf := time.Date(2023, time.March, 10, 0, 0, 0, 0, time.Local)
+	result, err := llm.GenerateCritiquedResponse("test event", client, llm.WithAsOf(asOf), llm.WithLogger(log))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("Expected predictor and critic calls, got %d", len(prompts))
+	}
+	for _, prompt := range prompts {
+		if !strings.Contains(prompt, "Today is 2023-03-10") {
+			t.Errorf("Expected the as-of date in the prompt, got: %s", prompt)
+		}
+	}
+	if result.AsOf != "2023-03-10" {
+		t.Errorf("Expected as_of 2023-03-10 in the output, got %q", result.AsOf)
+	}
+	if strings.Contains(logs.String(), "knowledge cutoff") {
+		t.Errorf("Expected no cutoff warning before the cutoff, got: %s", logs.String())
+	}
+
+	logs.Reset()
+	if _, err := llm.GenerateCritiquedResponse("test event", client, llm.WithAsOf(time.Date(2025, time.January, 31, 0, 0, 0, 0, time.Local)), llm.WithLogger(log)); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "knowledge cutoff") || !strings.Contains(logs.String(), "knowledge_cutoff=2023-10-01") {
+		t.Errorf("Expected a cutoff warning after the cutoff, got: %s", logs.String())
+	}
+}
+
+func TestBackendKnowledgeCutoff(t *testing.T) {
+	llmtest.Setup(t)
+	backends := []llm.Backend{
+		{Provider: "groq", Model: "llama3", BaseURL: "https://api.groq.com/openai/v1/chat/completions", KnowledgeCutoff: time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{Provider: "ollama", Model: "mystery", BaseURL: "http://localhost:11434/v1/chat/completions"},
+	}
+	client, err := llm.NewClient(llmtest.HTTPClient(llmtest.Answer), llm.WithBackends(backends...))
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+
+	var logs bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&logs, nil))
+	if _, err := llm.GenerateCritiquedResponse("test event", client, llm.WithAsOf(time.Date(2024, time.June, 3, 0, 0, 0, 0, time.Local)), llm.WithLogger(log)); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if strings.Count(logs.String(), "knowledge cutoff") != 1 || !strings.Contains(logs.String(), "backend=groq/llama3 knowledge_cutoff=2023-12-01") {
+		t.Errorf("Expected a warning for the configured cutoff only, got: %s", logs.String())
+	}
+}
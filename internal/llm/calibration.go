@@ -0,0 +1,35 @@
+package llm
+
+import "nostradamus/internal/models"
+
+// Calibrator maps the critic's confidences onto the rates at which comparable past
+// predictions came true, keeping the critic's values as RawConfidence
+type Calibrator interface {
+	Apply(r *models.CritiquedResponse) error
+}
+
+// WithCalibrator calibrates the critiqued confidences with c once the critique and debate
+// are over. It runs before the coherence check, so conflicts are totalled and normalised
+// on calibrated confidences while RawConfidence keeps the critic's.
+func WithCalibrator(c Calibrator) Option {
+	return func(o *options) {
+		o.calibrator = c
+	}
+}
+
+// calibrate applies the calibrator, if one is set, to critiqued
+func (p *pipeline) calibrate(critiqued *models.CritiquedResponse) error {
+	if p.opts.calibrator == nil {
+		return nil
+	}
+	stage := p.root.child("calibration", "stage", "calibration")
+	defer stage.span.End()
+	if err := p.opts.calibrator.Apply(critiqued); err != nil {
+		stage.span.SetError(err)
+		return err
+	}
+	if c := critiqued.Calibration; c != nil {
+		stage.log.Info("Calibrated confidences", "method", c.Method, "model", c.Model, "persona", c.Persona, "records", c.Records)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package llm_test
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/calibration"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+)
+
+func TestCalibrationBeforeCoherence(t *testing.T) {
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "for consistency"):
+			return http.StatusOK, `{"conflicts": [{"kind": "mutually_exclusive", "predictions": [0, 1], "explanation": "A withdrawal removes the need for an ultimatum"}]}`
+		case llmtest.IsCritique(body):
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "US issues ultimatum", "impact": "Defence rallies", "confidence": 0.7, "critique": "Likely"}, {"timeframe": "1 month", "description": "China withdraws troops", "impact": "Relief rally", "confidence": 0.6, "critique": "Plausible"}, {"timeframe": "1 year", "description": "Chipmakers diversify supply", "impact": "Capex rises", "confidence": 0.8, "critique": "Ongoing"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "US issues ultimatum", "impact": "Defence rallies"}, {"timeframe": "1 month", "description": "China withdraws troops", "impact": "Relief rally"}, {"timeframe": "1 year", "description": "Chipmakers diversify supply", "impact": "Capex rises"}]}`
+	})
+
+	// 0.6 came true 60% of the time, 0.7 80% and 0.8 90%
+	var records []calibration.Record
+	for i := 0; i < 10; i++ {
+		records = append(records,
+			calibration.Record{Confidence: 0.6, Outcome: i < 6},
+			calibration.Record{Confidence: 0.7, Outcome: i < 8},
+			calibration.Record{Confidence: 0.8, Outcome: i < 9},
+		)
+	}
+	calibrator, err := calibration.Fit(records, calibration.MethodIsotonic, 20)
+	if err != nil {
+		t.Fatalf("Failed to fit calibration: %v", err)
+	}
+
+	result, err := llm.GenerateCritiquedResponse("x", client, llm.WithCalibrator(calibrator), llm.WithCoherence(models.CoherenceNormalise))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(result.Conflicts) != 1 || math.Abs(result.Conflicts[0].Total-1.4) > 1e-9 || !result.Conflicts[0].Normalised {
+		t.Fatalf("Expected the conflict totalled on calibrated confidences, got %+v", result.Conflicts)
+	}
+	for i, want := range []struct{ raw, confidence float64 }{{0.7, 0.8 / 1.4}, {0.6, 0.6 / 1.4}, {0.8, 0.9}} {
+		p := result.Predictions[i]
+		if p.RawConfidence == nil || *p.RawConfidence != want.raw || math.Abs(p.Confidence-want.confidence) > 1e-9 {
+			t.Errorf("Prediction %d: expected raw %v calibrated and normalised to %v, got %+v", i, want.raw, want.confidence, p)
+		}
+	}
+	if result.Calibration == nil || result.Calibration.Records != 30 {
+		t.Errorf("Expected the calibration recorded, got %+v", result.Calibration)
+	}
+}
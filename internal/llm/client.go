@@ -6,70 +6,214 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"strings"
+	"time"
+
+	"nostradamus/internal/logger"
 )
 
-// Client represents an LLM API client
+// openAIURL is the chat completions endpoint of the default backend
+const openAIURL = "https://api.openai.com/v1/chat/completions"
+
+// Client represents an LLM API client. It calls its backends in priority order, failing
+// over to the next one when a call fails.
 type Client struct {
 	httpClient *http.Client
-	apiKey     string
-	baseURL    string
+	backends   []*backend
+	// breakerFailures and breakerCooldown configure the circuit breaker of every backend
+	breakerFailures int
+	breakerCooldown time.Duration
+	log             *slog.Logger
+	// limiters are keyed by limiterKey and shared by every call made with the client
+	limiters map[string]*limiter
+}
+
+// APIError is returned when the LLM API answers with a status other than 200
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+// Error describes the failed call with its status and response body
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
 }
 
-// NewClient creates a new LLM API client
-func NewClient(httpClient *http.Client) (*Client, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil, errors.New("OPENAI_API_KEY is not set")
+// ResponseBody returns the raw response body, so log redaction can recognise it
+func (e *APIError) ResponseBody() string {
+	return e.Body
+}
+
+// ClientOption configures a Client
+type ClientOption func(*Client)
+
+// WithClientLogger sets the logger used by the client and, unless overridden with
+// WithLogger, by the pipeline runs made with it
+func WithClientLogger(log *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.log = log
 	}
+}
 
-	return &Client{
-		httpClient: httpClient,
-		apiKey:     apiKey,
-		baseURL:    "https://api.openai.com/v1/chat/completions",
-	}, nil
+// NewClient creates a new LLM API client. Without WithBackends it calls OpenAI's o1-mini
+// with the key in OPENAI_API_KEY.
+func NewClient(httpClient *http.Client, opts ...ClientOption) (*Client, error) {
+	c := &Client{
+		httpClient:      httpClient,
+		breakerFailures: 5,
+		breakerCooldown: 30 * time.Second,
+		log:             logger.DefaultLogger.Logger,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if len(c.backends) == 0 {
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, errors.New("OPENAI_API_KEY is not set")
+		}
+		c.backends = []*backend{{Backend: Backend{Provider: "openai", Model: "o1-mini", BaseURL: openAIURL, APIKey: apiKey}}}
+	}
+	for _, b := range c.backends {
+		b.breaker = newBreaker(c.breakerFailures, c.breakerCooldown)
+	}
+	return c, nil
 }
 
 // CallLLM sends a request to the LLM API and returns the response
 func (c *Client) CallLLM(prompt string) (string, error) {
-	requestPayload := map[string]interface{}{
-		"model": "o1-mini",
+	return c.callLLM(scope{log: c.log}, prompt)
+}
+
+// CallLLMWithTemperature sends a request sampled at the given temperature and returns the response
+func (c *Client) CallLLMWithTemperature(prompt string, temperature float64) (string, error) {
+	return c.callLLMWithTemperature(scope{log: c.log}, prompt, temperature)
+}
+
+// callLLM is CallLLM reporting to sc, so pipeline runs keep their correlation attributes
+func (c *Client) callLLM(sc scope, prompt string) (string, error) {
+	response, _, err := c.send(sc, newRequestPayload(prompt))
+	return response, err
+}
+
+// callLLMWithTemperature is CallLLMWithTemperature reporting to sc
+func (c *Client) callLLMWithTemperature(sc scope, prompt string, temperature float64) (string, error) {
+	requestPayload := newRequestPayload(prompt)
+	requestPayload["temperature"] = temperature
+	response, _, err := c.send(sc, requestPayload)
+	return response, err
+}
+
+// newRequestPayload builds the chat completion payload for a single user prompt; the
+// model is set for each backend the payload is sent to
+func newRequestPayload(prompt string) map[string]interface{} {
+	return map[string]interface{}{
 		"messages": []map[string]string{
 			{"role": "user", "content": prompt},
 		},
 	}
-	
+}
+
+// sendTo posts the payload to backend b and returns the parsed response, tracing the
+// call in a client span under sc and recording it in sc's metrics
+func (c *Client) sendTo(sc scope, b *backend, requestPayload map[string]interface{}) (string, error) {
+	provider, model := b.Provider, b.Model
+	requestPayload["model"] = model
+	span := sc.span.ClientChild("POST")
+	defer span.End()
+	span.SetAttribute("llm.provider", provider)
+	span.SetAttribute("llm.model", model)
+	span.SetAttribute("http.request.method", "POST")
+	span.SetAttribute("url.full", b.BaseURL)
+
+	var usage *tokenUsage
+	if l := c.limiterFor(provider, model); l != nil {
+		estimated := estimateTokens(requestPayload)
+		waited := l.acquire(estimated)
+		defer func() { l.release(estimated, usage) }()
+		sc.metrics.observeWait(provider, model, waited)
+		if total := waited.total(); total > 0 {
+			sc.log.Debug("Waited for LLM rate limit", "duration", total)
+			span.SetAttribute("llm.rate_limit.wait_ms", total.Milliseconds())
+		}
+	}
+
+	started := time.Now()
+	response, status, usage, err := c.do(sc.log, b, requestPayload)
+	sc.metrics.observeCall(provider, model, status, time.Since(started), usage)
+	if status != 0 {
+		span.SetAttribute("http.response.status_code", status)
+	}
+	if usage != nil {
+		span.SetAttribute("llm.usage.prompt_tokens", usage.PromptTokens)
+		span.SetAttribute("llm.usage.completion_tokens", usage.CompletionTokens)
+		span.SetAttribute("llm.usage.total_tokens", usage.TotalTokens)
+	}
+	span.SetError(err)
+	return response, err
+}
+
+// do performs the HTTP call of sendTo and returns the parsed response along with the
+// HTTP status (0 when no response was received) and the token usage, if reported
+func (c *Client) do(log *slog.Logger, b *backend, requestPayload map[string]interface{}) (string, int, *tokenUsage, error) {
 	requestBody, err := json.Marshal(requestPayload)
 	if err != nil {
-		return "", err
+		return "", 0, nil, err
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequest("POST", b.BaseURL, bytes.NewBuffer(requestBody))
 	if err != nil {
-		return "", err
+		return "", 0, nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if b.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.APIKey)
+	}
 
+	started := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		log.Debug("LLM HTTP request failed", "duration", time.Since(started), "error", err)
+		return "", 0, nil, err
 	}
 	defer resp.Body.Close()
+	log.Debug("LLM HTTP request completed", "status", resp.StatusCode, "duration", time.Since(started))
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", resp.StatusCode, nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return "", resp.StatusCode, nil, &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
-	return c.parseResponse(bodyBytes)
+	usage := parseUsage(bodyBytes)
+	response, err := c.parseResponse(bodyBytes)
+	return response, resp.StatusCode, usage, err
+}
+
+// tokenUsage is the token accounting reported by the LLM API
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// parseUsage extracts the token usage from a chat completion response, or nil if absent
+func parseUsage(bodyBytes []byte) *tokenUsage {
+	var response struct {
+		Usage *tokenUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil
+	}
+	return response.Usage
 }
 
 func (c *Client) parseResponse(bodyBytes []byte) (string, error) {
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"nostradamus/internal/models"
+)
+
+// WithCoherence adds a stage after the critique that finds mutually exclusive and
+// contradictory predictions. mode is models.CoherenceFlag to report them or
+// models.CoherenceNormalise to also scale inconsistent confidences; empty skips the stage.
+func WithCoherence(mode string) Option {
+	return func(o *options) {
+		o.coherence = mode
+	}
+}
+
+// coherencePrediction is the view of a prediction the coherence check is given
+type coherencePrediction struct {
+	Index       int      `json:"index"`
+	Timeframe   string   `json:"timeframe"`
+	Description string   `json:"description"`
+	Impact      string   `json:"impact"`
+	Sectors     []string `json:"sectors,omitempty"`
+	Direction   string   `json:"direction,omitempty"`
+	// Probability is the overall probability of the prediction, not the one given its parents
+	Probability float64 `json:"probability"`
+}
+
+// checkCoherence asks the LLM which predictions of critiqued cannot all come true and
+// records them as conflicts, normalising confidences when requested. A failed check is
+// logged and leaves critiqued unchanged.
+func (p *pipeline) checkCoherence(critiqued *models.CritiquedResponse) {
+	if p.opts.coherence == "" || len(critiqued.Predictions) < 2 {
+		return
+	}
+	view := make([]coherencePrediction, len(critiqued.Predictions))
+	for i, prediction := range critiqued.Predictions {
+		view[i] = coherencePrediction{
+			Index:       i,
+			Timeframe:   prediction.Timeframe,
+			Description: prediction.Description,
+			Impact:      prediction.Impact,
+			Sectors:     prediction.Sectors,
+			Direction:   prediction.Direction,
+			Probability: prediction.Likelihood(),
+		}
+	}
+	predictions, err := json.Marshal(view)
+	if err != nil {
+		p.root.log.Error("Coherence check failed", "error", err)
+		return
+	}
+	check, err := callWithRetry(p.root, p.client.callLLM, "coherence", coherencePrompt(p.input, string(predictions)), func(c *models.CoherenceCheck) error {
+		return c.Validate(len(critiqued.Predictions))
+	})
+	if err != nil {
+		p.stageLog("coherence").Error("Coherence check failed", "error", err)
+		return
+	}
+	critiqued.ApplyConflicts(check.Conflicts, p.opts.coherence)
+	for _, c := range critiqued.Conflicts {
+		if c.Inconsistent {
+			p.stageLog("coherence").Warn("Conflicting predictions have a combined confidence above 1",
+				"kind", c.Kind, "predictions", c.Predictions, "total", c.Total, "normalised", c.Normalised)
+		}
+	}
+}
+
+// coherencePrompt builds the prompt asking which of the predictions made for input cannot
+// all come true
+func coherencePrompt(input, predictions string) string {
+	return fmt.Sprintf("You are checking stock market predictions made for the event %q for consistency. Find the groups of two or more predictions that cannot all come true: \"mutually_exclusive\" when they describe alternative outcomes of which at most one can happen, and \"contradiction\" when one asserts the opposite of another, such as a sector rising and falling over the same timeframe. Predictions that can happen together, however unlikely, are not in conflict. Return JSON with \"conflicts\", an array that is empty when the predictions are consistent, of objects with \"kind\", one of [%s, %s], \"predictions\", an array of the indexes of the predictions in the group, and \"explanation\", one sentence on why they cannot all come true. Predictions: %s", input, models.ConflictExclusive, models.ConflictContradiction, predictions)
+}
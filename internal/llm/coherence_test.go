@@ -0,0 +1,100 @@
+package llm_test
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+)
+
+func TestCoherenceCheck(t *testing.T) {
+	checks := 0
+	var checkPrompt string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "for consistency"):
+			checks++
+			checkPrompt = body
+			if checks == 1 {
+				return http.StatusOK, `{"conflicts": [{"kind": "mutually_exclusive", "predictions": [0, 3], "explanation": "Out of range"}]}`
+			}
+			return http.StatusOK, `{"conflicts": [{"kind": "mutually_exclusive", "predictions": [0, 1, 1], "explanation": "A withdrawal removes the need for an ultimatum"}]}`
+		case llmtest.IsCritique(body):
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "US issues ultimatum", "impact": "Defence rallies", "confidence": 0.7, "critique": "Likely"}, {"timeframe": "1 month", "description": "China withdraws troops", "impact": "Relief rally", "confidence": 0.6, "critique": "Plausible"}, {"timeframe": "1 year", "description": "Chipmakers diversify supply", "impact": "Capex rises", "confidence": 0.8, "critique": "Ongoing"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "US issues ultimatum", "impact": "Defence rallies"}, {"timeframe": "1 month", "description": "China withdraws troops", "impact": "Relief rally"}, {"timeframe": "1 year", "description": "Chipmakers diversify supply", "impact": "Capex rises"}]}`
+	})
+
+	result, err := llm.GenerateCritiquedResponse("x", client, llm.WithCoherence(models.CoherenceNormalise))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if checks != 2 || !strings.Contains(checkPrompt, `\"index\":2`) {
+		t.Errorf("Expected the out-of-range conflict retried with indexed predictions, got %d checks: %s", checks, checkPrompt)
+	}
+	if len(result.Conflicts) != 1 {
+		t.Fatalf("Expected one conflict, got %+v", result.Conflicts)
+	}
+	conflict := result.Conflicts[0]
+	if len(conflict.Predictions) != 2 || math.Abs(conflict.Total-1.3) > 1e-9 || !conflict.Inconsistent || !conflict.Normalised {
+		t.Errorf("Unexpected conflict: %+v", conflict)
+	}
+	if math.Abs(result.Predictions[0].Confidence-0.7/1.3) > 1e-9 || math.Abs(result.Predictions[1].Confidence-0.6/1.3) > 1e-9 || result.Predictions[2].Confidence != 0.8 {
+		t.Errorf("Expected the conflicting confidences normalised to sum to 1, got %+v", result.Predictions)
+	}
+
+	checks = 1
+	result, err = llm.GenerateCritiquedResponse("x", client, llm.WithCoherence(models.CoherenceFlag))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Normalised || !result.Conflicts[0].Inconsistent || result.Predictions[0].Confidence != 0.7 {
+		t.Errorf("Expected the conflict flagged with confidences unchanged, got %+v", result)
+	}
+}
+
+func TestCoherenceOnPredictionGraph(t *testing.T) {
+	// b follows from a, and c excludes b
+	cConfidence := "0.3"
+	var checkPrompt string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "for consistency"):
+			checkPrompt = body
+			return http.StatusOK, `{"conflicts": [{"kind": "mutually_exclusive", "predictions": [1, 2], "explanation": "Housing starts cannot both rebound and slump"}]}`
+		case llmtest.IsCritique(body):
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "a", "timeframe": "1 month", "description": "Fed cuts rates", "impact": "Bonds rally", "confidence": 0.5, "critique": "Even odds"}, {"id": "b", "parents": ["a"], "timeframe": "3 months", "description": "Housing starts rebound", "impact": "Builders rise", "confidence": 0.9, "critique": "Likely after a cut"}, {"id": "c", "timeframe": "3 months", "description": "Housing starts slump", "impact": "Builders fall", "confidence": ` + cConfidence + `, "critique": "Possible"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "a", "timeframe": "1 month", "description": "Fed cuts rates", "impact": "Bonds rally"}, {"id": "b", "parents": ["a"], "timeframe": "3 months", "description": "Housing starts rebound", "impact": "Builders rise"}, {"id": "c", "timeframe": "3 months", "description": "Housing starts slump", "impact": "Builders fall"}]}`
+	})
+
+	// b is 0.9 given a but 0.45 overall, so b and c total 0.75 and are consistent
+	result, err := llm.GenerateCritiquedResponse("x", client, llm.WithCoherence(models.CoherenceNormalise))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(checkPrompt, `\"probability\":0.45`) {
+		t.Errorf("Expected the checker given overall probabilities, got: %s", checkPrompt)
+	}
+	if len(result.Conflicts) != 1 || math.Abs(result.Conflicts[0].Total-0.75) > 1e-9 || result.Conflicts[0].Inconsistent || result.Predictions[1].Confidence != 0.9 {
+		t.Errorf("Expected a consistent conflict totalling 0.75, got %+v", result.Conflicts)
+	}
+
+	// With c at 0.7 the pair totals 1.15, and their overall probabilities are scaled to sum to 1
+	cConfidence = "0.7"
+	result, err = llm.GenerateCritiquedResponse("x", client, llm.WithCoherence(models.CoherenceNormalise))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(result.Conflicts) != 1 || math.Abs(result.Conflicts[0].Total-1.15) > 1e-9 || !result.Conflicts[0].Normalised {
+		t.Fatalf("Expected the conflict normalised, got %+v", result.Conflicts)
+	}
+	b, c := result.Predictions[1], result.Predictions[2]
+	if math.Abs(b.Probability+c.Probability-1) > 1e-9 || math.Abs(b.Confidence-0.9/1.15) > 1e-9 || math.Abs(b.Probability-b.Confidence*0.5) > 1e-9 {
+		t.Errorf("Expected overall probabilities summing to 1 with b's confidence given a, got %+v and %+v", b, c)
+	}
+}
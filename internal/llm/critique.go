@@ -8,53 +8,219 @@ import (
 	"time"
 
 	"nostradamus/internal/config"
-	"nostradamus/internal/logger"
+	"nostradamus/internal/models"
 )
 
 // GenerateCritiquedPredictions calls the LLM API to first generate predictions and then critiques them.
-// It retries up to 10 times until it gets a valid JSON response.
-func GenerateCritiquedPredictions(input string, client *Client) (string, error) {
+// It retries up to config.MaxAttempts times until it gets a valid JSON response.
+func GenerateCritiquedPredictions(input string, client *Client, opts ...Option) (string, error) {
+	result, err := GenerateCritiquedResponse(input, client, opts...)
+	if err != nil {
+		return "", err
+	}
+	finalResponse, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(finalResponse), nil
+}
+
+// GenerateCritiquedResponse runs the prediction, critique and optional debate and sampling
+// stages and returns the critiqued predictions
+func GenerateCritiquedResponse(input string, client *Client, opts ...Option) (*models.CritiquedResponse, error) {
+	o := newOptions(opts)
 	if strings.TrimSpace(input) == "" {
-		return "", errors.New("no input provided")
+		return nil, errors.New("no input provided")
 	}
 
-	// Build the initial prediction prompt.
-	predictionPrompt := fmt.Sprintf("You are a predictor of future stock market events. Given the event: %q, generate predictions in JSON format. The JSON output must have \"original_prompt\" equal to the input and \"predictions\" be an array with between 1 and 10 items with each item containing \"timeframe\", \"description\", and \"impact\". The timeframe must be given in the format \"X {weeks, months, years}\", where X is the when the preditions will occur. The impact is a short sentence explaining the impact on the market and the industry likely to be impacted. The description is a short paragraph of two to four sentences explaining in more details the prediction.", input)
-	initialResponse, err := client.CallLLM(predictionPrompt)
+	p := newPipeline(input, client, o)
+	started := time.Now()
+	critiqued, err := p.run()
+	o.metrics.observeRun(time.Since(started), err)
+	p.root.span.SetError(err)
+	if exportErr := p.root.span.End(); exportErr != nil {
+		p.root.log.Error("Failed to export trace", "error", exportErr)
+	}
+	return critiqued, err
+}
+
+// run executes the stages of the pipeline
+func (p *pipeline) run() (*models.CritiquedResponse, error) {
+	p.root.log.Info("Starting prediction run")
+	p.warnAfterCutoff()
+	p.retrieve()
+	p.findAnalogs()
+
+	initialResponse, sampled, err := p.initialPredictions()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var lastErr error
-	for attempt := 1; attempt <= 10; attempt++ {
-		// Build the critique prompt using the initial predictions.
-		critiquePrompt := fmt.Sprintf("You are a knowledgeable investor. Critically review the following predictions in JSON format and add two additional fields to each prediction: \"confidence\" (a float between 0 and 1) and \"critique\" (a string explaining why this prediction is likely or not). The critique field is a short paragraph of two to three sentences explaining how you reason about the confidence rating you gave to the event. Input predictions: %s", initialResponse)
-		critiqueResponse, err := client.CallLLM(critiquePrompt)
-		if err != nil {
-			logger.Error("Critique API call failed", "attempt", attempt, "error", err)
-			lastErr = err
-			time.Sleep(config.RetryDelay)
-			continue
+	critiqued, err := p.critique(p.root, p.dedupeJSON(initialResponse))
+	if err != nil {
+		return nil, err
+	}
+	if p.opts.debateRounds > 0 {
+		critiqued = p.debate(critiqued)
+	}
+	if sampled != nil {
+		applyFrequencies(critiqued.Predictions, sampled, p.opts.clusterThreshold)
+		if critiqued.Trace == nil {
+			critiqued.Trace = &models.RunTrace{}
+		}
+		critiqued.Trace.Samples = p.opts.samples
+	}
+	critiqued.Persona = p.opts.persona
+	if err := p.calibrate(critiqued); err != nil {
+		return nil, err
+	}
+	// Conflicts are totalled on overall probabilities, and normalisation rescales the
+	// confidences descendants are propagated from, so propagation runs on both sides
+	if critiqued.HasGraph() {
+		if err := critiqued.PropagateConfidence(); err != nil {
+			return nil, err
+		}
+	}
+	p.checkCoherence(critiqued)
+	if critiqued.HasGraph() {
+		if err := critiqued.PropagateConfidence(); err != nil {
+			return nil, err
 		}
+	}
+	critiqued.AsOf = p.opts.asOf.Format("2006-01-02")
+	critiqued.Passages = p.passages
+	critiqued.Analogs = p.analogs
+	critiqued.Merges = p.merges
+	p.root.log.Info("Finished prediction run", "predictions", len(critiqued.Predictions))
+	return critiqued, nil
+}
+
+// initialPredictions asks the predictor for the predictions to critique. When several
+// samples are requested it also returns the sampled clusters behind them.
+func (p *pipeline) initialPredictions() (string, []sampledPrediction, error) {
+	if p.opts.samples <= 1 {
+		stage := p.root.child("prediction", "stage", "prediction")
+		defer stage.span.End()
+		attempt := stage.child("attempt", "attempt", 1)
+		defer attempt.span.End()
 
-		// Validate JSON response
-		var tmp map[string]interface{}
-		if err = json.Unmarshal([]byte(critiqueResponse), &tmp); err != nil {
-			logger.Error("Invalid JSON in critique response", "attempt", attempt, "error", err)
-			lastErr = err
-			time.Sleep(config.RetryDelay)
-			continue
+		attempt.log.Debug("Calling LLM")
+		response, err := p.client.callLLM(attempt, p.predictionPrompt())
+		if err != nil {
+			attempt.log.Error("LLM API call failed", "error", err)
 		}
+		attempt.span.SetError(err)
+		stage.span.SetError(err)
+		return response, nil, err
+	}
+
+	sampled, err := p.samplePredictions()
+	if err != nil {
+		return "", nil, err
+	}
+	initialBytes, err := json.Marshal(models.PredictionResponse{OriginalPrompt: p.input, Predictions: representatives(sampled)})
+	if err != nil {
+		return "", nil, err
+	}
+	return string(initialBytes), sampled, nil
+}
+
+// critique asks the critic to score the given predictions JSON, reporting under parent.
+// The response records the backend that produced it.
+func (p *pipeline) critique(parent scope, predictions string) (*models.CritiquedResponse, error) {
+	var backend string
+	call := func(sc scope, prompt string) (string, error) {
+		response, served, err := p.client.send(sc, newRequestPayload(prompt))
+		backend = served
+		return response, err
+	}
+	critiqued, err := callWithRetry(parent, call, "critique", p.critiquePrompt(predictions), func(r *models.CritiquedResponse) error {
 		// Force "original_prompt" to be only the user input
-		tmp["original_prompt"] = input
-		finalResponse, err := json.Marshal(tmp)
-		if err != nil {
-			logger.Error("Failed to marshal final response", "error", err)
-			lastErr = err
-			time.Sleep(config.RetryDelay)
-			continue
+		r.OriginalPrompt = p.input
+		if err := r.Validate(); err != nil {
+			return err
+		}
+		if err := r.CheckSources(p.sources); err != nil {
+			return err
+		}
+		return r.CheckTickers(p.opts.tickers)
+	})
+	if err != nil {
+		return nil, err
+	}
+	critiqued.Backend = backend
+	return critiqued, nil
+}
+
+// validatePredictions validates and normalises a predictor response
+func (p *pipeline) validatePredictions(r *models.PredictionResponse) error {
+	r.OriginalPrompt = p.input
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if err := r.CheckSources(p.sources); err != nil {
+		return err
+	}
+	return r.CheckTickers(p.opts.tickers)
+}
+
+// callWithRetry sends prompt through call up to config.MaxAttempts times until the response
+// decodes into a T that passes validate. The stage and each attempt get their own span
+// under parent, and their records carry the stage name and attempt number.
+func callWithRetry[T any](parent scope, call func(scope, string) (string, error), stage, prompt string, validate func(*T) error) (*T, error) {
+	stageScope := parent.child(stage, "stage", stage)
+	defer stageScope.span.End()
+
+	maxAttempts := config.MaxAttempts
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err := tryAttempt(stageScope.child("attempt", "attempt", attempt), call, prompt, validate)
+		if err == nil {
+			stageScope.span.SetAttribute("attempts", attempt)
+			stageScope.span.SetError(nil)
+			return result, nil
+		}
+		lastErr = err
+		stageScope.metrics.observeFailure(stage, err, attempt < maxAttempts)
+		time.Sleep(config.RetryDelay)
+	}
+	err := fmt.Errorf("failed after %d %s attempts: last error: %w", maxAttempts, stage, lastErr)
+	stageScope.span.SetAttribute("attempts", maxAttempts)
+	stageScope.span.SetError(err)
+	return nil, err
+}
+
+// tryAttempt makes a single call of callWithRetry, reporting to sc
+func tryAttempt[T any](sc scope, call func(scope, string) (string, error), prompt string, validate func(*T) error) (*T, error) {
+	defer sc.span.End()
+
+	sc.log.Debug("Calling LLM")
+	response, err := call(sc, prompt)
+	if err != nil {
+		sc.log.Error("LLM API call failed", "error", err)
+		sc.span.SetError(err)
+		return nil, err
+	}
+
+	result := new(T)
+	if err = json.Unmarshal([]byte(response), result); err != nil {
+		err = &models.ValidationError{Rule: models.RuleInvalidJSON, Message: err.Error()}
+		sc.log.Error("Invalid JSON in LLM response", "error", err)
+		sc.span.SetAttribute("validation.rule", models.RuleInvalidJSON)
+		sc.span.SetError(err)
+		return nil, err
+	}
+	if err = validate(result); err != nil {
+		sc.log.Error("LLM response does not match the output structure", "error", err)
+		// Only the rule is traced: the message quotes the response, which exporters
+		// write out unredacted
+		var validationErr *models.ValidationError
+		if errors.As(err, &validationErr) {
+			sc.span.SetAttribute("validation.rule", validationErr.Rule)
 		}
-		return string(finalResponse), nil
+		sc.span.SetError(err)
+		return nil, err
 	}
-	return "", fmt.Errorf("failed after 10 critique attempts: last error: %v", lastErr)
+	sc.span.SetError(nil)
+	return result, nil
 }
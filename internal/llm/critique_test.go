@@ -0,0 +1,78 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+)
+
+func TestCritiquedUnknownSectorRetries(t *testing.T) {
+	critiqueCount := 0
+	var predictionBody string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			predictionBody = body
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "sectors": ["Energy"], "direction": "bearish", "magnitude": "high", "tickers": ["XOM"]}]}`
+		}
+		critiqueCount++
+		sector := "Crypto"
+		if critiqueCount > 1 {
+			sector = "Energy"
+		}
+		return http.StatusOK, fmt.Sprintf(`{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "sectors": [%q], "direction": "bearish", "magnitude": "high", "tickers": ["XOM"], "confidence": 0.6, "critique": "Plausible"}]}`, sector)
+	})
+
+	result, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithTickers(models.TickerSet{"XOM": true}))
+	if err != nil {
+		t.Fatalf("Expected valid tagged response, got error: %v", err)
+	}
+	if critiqueCount != 2 {
+		t.Errorf("Expected the unknown sector to trigger a retry, got %d critique calls", critiqueCount)
+	}
+	if !strings.Contains(predictionBody, "GICS sectors") || !strings.Contains(predictionBody, "[XOM]") {
+		t.Errorf("Expected predictor prompt to request tags and list tickers, got: %s", predictionBody)
+	}
+	var cr models.CritiquedResponse
+	if err := json.Unmarshal([]byte(result), &cr); err != nil {
+		t.Fatalf("Failed to parse tagged response: %v", err)
+	}
+	pred := cr.Predictions[0]
+	if len(pred.Sectors) != 1 || pred.Sectors[0] != "Energy" || pred.Direction != "bearish" || pred.Magnitude != "high" {
+		t.Errorf("Expected tags to be kept in the output, got: %+v", pred)
+	}
+}
+
+func TestPipelinePropagatesConditionalConfidence(t *testing.T) {
+	critiques := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			if !strings.Contains(body, `\"parents\"`) {
+				t.Errorf("Expected graph instructions in the predictor prompt, got: %s", body)
+			}
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "p1", "timeframe": "2 weeks", "description": "Gas supply drops", "impact": "Shock"}, {"id": "p2", "parents": ["p1"], "timeframe": "1 month", "description": "Energy prices rise", "impact": "Rally"}]}`
+		}
+		critiques++
+		parent := "p1"
+		if critiques == 1 {
+			parent = "p2"
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "p1", "timeframe": "2 weeks", "description": "Gas supply drops", "impact": "Shock", "confidence": 0.5, "critique": "Maybe"}, {"id": "p2", "parents": ["` + parent + `"], "timeframe": "1 month", "description": "Energy prices rise", "impact": "Rally", "confidence": 0.8, "critique": "Follows"}]}`
+	})
+	result, err := llm.GenerateCritiquedResponse("x", client)
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if critiques != 2 {
+		t.Errorf("Expected the cyclic critique retried, got %d critiques", critiques)
+	}
+	if result.Predictions[0].Probability != 0.5 || math.Abs(result.Predictions[1].Probability-0.4) > 1e-9 {
+		t.Errorf("Expected propagated probabilities 0.5 and 0.4, got %+v", result.Predictions)
+	}
+}
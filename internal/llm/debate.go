@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// debate runs up to opts.debateRounds rounds in which the predictor revises its predictions
+// from the critic's feedback and the critic scores the revision. It stops early once
+// confidences shift by no more than opts.debateTolerance between two rounds. Every round is
+// kept in the trace of the returned response as the critic scored it, unaffected by later
+// stages; if a round fails, the last complete round wins.
+func (p *pipeline) debate(initial *models.CritiquedResponse) *models.CritiquedResponse {
+	log := p.stageLog("debate")
+	debateScope := scope{log: p.root.log, span: p.root.span.Child("debate"), metrics: p.root.metrics}
+	defer debateScope.span.End()
+	trace := &models.RunTrace{
+		Rounds: []models.DebateRound{{Round: 0, Predictions: slices.Clone(initial.Predictions)}},
+	}
+	current := initial
+	for round := 1; round <= p.opts.debateRounds; round++ {
+		roundScope := debateScope.child("round", "round", round)
+		next, err := p.debateRound(roundScope, current)
+		roundScope.span.SetError(err)
+		roundScope.span.End()
+		if err != nil {
+			log.Error("Debate round failed", "round", round, "error", err)
+			break
+		}
+
+		shift := confidenceShift(current.Predictions, next.Predictions)
+		trace.Rounds = append(trace.Rounds, models.DebateRound{Round: round, Predictions: slices.Clone(next.Predictions), ConfidenceShift: shift})
+		current = next
+		if shift <= p.opts.debateTolerance {
+			trace.Stabilised = true
+			break
+		}
+	}
+	current.Trace = trace
+	return current
+}
+
+// debateRound has the predictor revise current from the critic's feedback and the critic
+// score the revision, reporting under sc
+func (p *pipeline) debateRound(sc scope, current *models.CritiquedResponse) (*models.CritiquedResponse, error) {
+	feedback, err := json.Marshal(current.Predictions)
+	if err != nil {
+		return nil, err
+	}
+	revised, err := callWithRetry(sc, p.client.callLLM, "revision", p.revisionPrompt(string(feedback)), p.validatePredictions)
+	if err != nil {
+		return nil, err
+	}
+	p.dedupe(revised)
+	revisedJSON, err := json.Marshal(revised)
+	if err != nil {
+		return nil, err
+	}
+	return p.critique(sc, string(revisedJSON))
+}
+
+// confidenceShift returns the largest confidence change between predictions with the
+// same description in two rounds. A prediction that was added or dropped counts as a
+// full shift of 1.
+func confidenceShift(previous, next []models.CritiquedPrediction) float64 {
+	if len(previous) != len(next) {
+		return 1
+	}
+	confidences := make(map[string]float64, len(previous))
+	for _, p := range previous {
+		confidences[normaliseDescription(p.Description)] = p.Confidence
+	}
+	shift := 0.0
+	for _, p := range next {
+		before, ok := confidences[normaliseDescription(p.Description)]
+		if !ok {
+			return 1
+		}
+		shift = math.Max(shift, math.Abs(p.Confidence-before))
+	}
+	return shift
+}
+
+// normaliseDescription lowercases a description and collapses its whitespace so
+// cosmetic edits do not count as a new prediction
+func normaliseDescription(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,106 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/calibration"
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+)
+
+func TestDebateRoundsStabilise(t *testing.T) {
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "revising your own work"):
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "2 weeks", "description": "Event A", "impact": "Market volatility"}]}`
+		case strings.Contains(body, "predictor of future stock market events"):
+			return http.StatusOK, llmtest.Predictions
+		case llmtest.IsCritique(body):
+			critiqueCount++
+			confidences := []string{"0.5", "0.7", "0.72"}
+			return http.StatusOK, fmt.Sprintf(`{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "confidence": %s, "critique": "Plausible"}]}`, confidences[critiqueCount-1])
+		}
+		t.Errorf("Unexpected request: %s", body)
+		return http.StatusBadRequest, "unexpected request"
+	})
+
+	result, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithDebateRounds(5))
+	if err != nil {
+		t.Fatalf("Expected valid debate response, got error: %v", err)
+	}
+	var cr models.CritiquedResponse
+	if err := json.Unmarshal([]byte(result), &cr); err != nil {
+		t.Fatalf("Failed to parse debate response: %v", err)
+	}
+	if critiqueCount != 3 {
+		t.Errorf("Expected 3 critique calls before stabilising, got: %d", critiqueCount)
+	}
+	if cr.Trace == nil || len(cr.Trace.Rounds) != 3 {
+		t.Fatalf("Expected 3 rounds in the trace, got: %+v", cr.Trace)
+	}
+	if !cr.Trace.Stabilised {
+		t.Error("Expected debate to be marked as stabilised")
+	}
+	if cr.Predictions[0].Confidence != 0.72 {
+		t.Errorf("Expected final confidence 0.72, got: %f", cr.Predictions[0].Confidence)
+	}
+	if cr.Trace.Rounds[0].Predictions[0].Confidence != 0.5 {
+		t.Errorf("Expected round 0 confidence 0.5, got: %f", cr.Trace.Rounds[0].Predictions[0].Confidence)
+	}
+}
+
+func TestDebateTraceUnchangedByCalibration(t *testing.T) {
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			return http.StatusOK, llmtest.Predictions
+		}
+		critiqueCount++
+		confidences := []string{"0.5", "0.9", "0.9"}
+		return http.StatusOK, fmt.Sprintf(`{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "confidence": %s, "critique": "Plausible"}]}`, confidences[critiqueCount-1])
+	})
+
+	// Predictions rated 0.5 and 0.9 alike came true 30% of the time
+	var records []calibration.Record
+	for i := 0; i < 10; i++ {
+		records = append(records, calibration.Record{Confidence: 0.5, Outcome: i < 3}, calibration.Record{Confidence: 0.9, Outcome: i < 3})
+	}
+	calibrator, err := calibration.Fit(records, calibration.MethodIsotonic, 20)
+	if err != nil {
+		t.Fatalf("Failed to fit calibration: %v", err)
+	}
+
+	result, err := llm.GenerateCritiquedResponse("test event", client, llm.WithDebateRounds(2), llm.WithCalibrator(calibrator))
+	if err != nil {
+		t.Fatalf("Expected valid debate response, got error: %v", err)
+	}
+	if math.Abs(result.Predictions[0].Confidence-0.3) > 1e-9 {
+		t.Errorf("Expected the final confidence calibrated to 0.3, got %+v", result.Predictions[0])
+	}
+	if result.Trace == nil || len(result.Trace.Rounds) != 3 {
+		t.Fatalf("Expected 3 rounds in the trace, got: %+v", result.Trace)
+	}
+	for i, want := range []float64{0.5, 0.9, 0.9} {
+		if p := result.Trace.Rounds[i].Predictions[0]; p.Confidence != want || p.RawConfidence != nil {
+			t.Errorf("Expected round %d to keep the critic's %v, got %+v", i, want, p)
+		}
+	}
+}
+
+func TestNoDebateRoundsOmitsTrace(t *testing.T) {
+	client := llmtest.NewClient(t, llmtest.Answer)
+	result, err := llm.GenerateCritiquedPredictions("test event", client)
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if strings.Contains(result, `"trace"`) {
+		t.Errorf("Expected no trace without debate rounds, got: %s", result)
+	}
+}
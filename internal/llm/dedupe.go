@@ -0,0 +1,247 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/similarity"
+)
+
+// WithDedupe merges predictions whose similarity reaches threshold before they are
+// critiqued. Similarity is lexical, averaged with the cosine similarity of embeddings
+// when an embedder is set with WithEmbedder.
+func WithDedupe(threshold float64) Option {
+	return func(o *options) {
+		o.dedupe = true
+		o.dedupeThreshold = threshold
+	}
+}
+
+// WithEmbedder sets the embedder used to compare predictions when merging duplicates
+func WithEmbedder(e Embedder) Option {
+	return func(o *options) {
+		o.embedder = e
+	}
+}
+
+// dedupeJSON merges the near-duplicates of the predictions JSON. Responses that do not
+// decode are returned unchanged for the critique to reject.
+func (p *pipeline) dedupeJSON(predictions string) string {
+	if !p.opts.dedupe {
+		return predictions
+	}
+	var r models.PredictionResponse
+	if err := json.Unmarshal([]byte(predictions), &r); err != nil {
+		return predictions
+	}
+	merges := p.dedupe(&r)
+	if len(merges) == 0 {
+		return predictions
+	}
+	deduped, err := json.Marshal(r)
+	if err != nil {
+		return predictions
+	}
+	p.merges = merges
+	return string(deduped)
+}
+
+// dedupe merges the near-duplicate predictions of r and returns the merges made. Each
+// group of duplicates keeps the prediction with the earliest timeframe, the descriptions
+// of all its members and the union of their tags. Predictions with different directions
+// are never merged.
+func (p *pipeline) dedupe(r *models.PredictionResponse) []models.Merge {
+	if !p.opts.dedupe || len(r.Predictions) < 2 {
+		return nil
+	}
+	stage := p.root.child("dedupe", "stage", "dedupe")
+	defer stage.span.End()
+
+	score := p.similarityScore(stage, r.Predictions)
+	groups := similarity.Group(len(r.Predictions), score, p.opts.dedupeThreshold)
+	if split := byDirection(r.Predictions, groups); len(split) > len(groups) {
+		stage.log.Info("Kept near-duplicate predictions with different directions apart", "groups", len(groups), "split", len(split))
+		groups = split
+	}
+	if len(groups) == len(r.Predictions) {
+		return nil
+	}
+
+	var merges []models.Merge
+	renamed := make(map[string]string)
+	merged := make([]models.Prediction, 0, len(groups))
+	for _, members := range groups {
+		if len(members) == 1 {
+			merged = append(merged, r.Predictions[members[0]])
+			continue
+		}
+		members = byEarliestTimeframe(r.Predictions, members)
+		kept := mergePredictions(r.Predictions, members)
+		merge := models.Merge{Timeframe: kept.Timeframe, Description: kept.Description}
+		for _, m := range members[1:] {
+			duplicate := r.Predictions[m]
+			merge.Duplicates = append(merge.Duplicates, models.MergedPrediction{
+				Timeframe:   duplicate.Timeframe,
+				Description: duplicate.Description,
+				Similarity:  score(members[0], m),
+			})
+			switch {
+			case duplicate.ID == "":
+			case kept.ID == "":
+				// The duplicate's children keep their parent under its ID
+				kept.ID = duplicate.ID
+			default:
+				renamed[duplicate.ID] = kept.ID
+			}
+		}
+		merges = append(merges, merge)
+		merged = append(merged, kept)
+	}
+	relink(merged, renamed)
+	if err := (models.PredictionResponse{Predictions: merged}).ValidateGraph(); err != nil {
+		stage.log.Warn("Merging duplicates broke the causal graph, dropping it", "error", err)
+		for i := range merged {
+			merged[i].Parents = nil
+		}
+	}
+
+	stage.span.SetAttribute("merged", len(r.Predictions)-len(merged))
+	stage.log.Info("Merged near-duplicate predictions", "before", len(r.Predictions), "after", len(merged))
+	r.Predictions = merged
+	return merges
+}
+
+// byDirection splits every group of predictions into one group per direction. Members
+// without a direction go with the first direction of their group.
+func byDirection(predictions []models.Prediction, groups [][]int) [][]int {
+	var split [][]int
+	for _, members := range groups {
+		direction := func(m int) string {
+			return strings.ToLower(strings.TrimSpace(predictions[m].Direction))
+		}
+		first := ""
+		for _, m := range members {
+			if first = direction(m); first != "" {
+				break
+			}
+		}
+		var directions []string
+		subgroups := make(map[string][]int)
+		for _, m := range members {
+			d := direction(m)
+			if d == "" {
+				d = first
+			}
+			if _, ok := subgroups[d]; !ok {
+				directions = append(directions, d)
+			}
+			subgroups[d] = append(subgroups[d], m)
+		}
+		for _, d := range directions {
+			split = append(split, subgroups[d])
+		}
+	}
+	return split
+}
+
+// similarityScore returns the score comparing two predictions by index: the lexical
+// similarity of their descriptions, averaged with the cosine similarity of their
+// embeddings when an embedder is set. If embedding fails only the lexical score is used.
+func (p *pipeline) similarityScore(sc scope, predictions []models.Prediction) func(i, j int) float64 {
+	texts := make([]string, len(predictions))
+	for i, prediction := range predictions {
+		texts[i] = prediction.Description
+	}
+	lexical := func(i, j int) float64 {
+		return similarity.Lexical(texts[i], texts[j])
+	}
+	if p.opts.embedder == nil {
+		return lexical
+	}
+	embeddings, err := p.opts.embedder.Embed(texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("got %d embeddings for %d predictions", len(embeddings), len(texts))
+	}
+	if err != nil {
+		sc.log.Warn("Embedding predictions failed, comparing them lexically", "error", err)
+		return lexical
+	}
+	return func(i, j int) float64 {
+		return (lexical(i, j) + similarity.Cosine(embeddings[i], embeddings[j])) / 2
+	}
+}
+
+// byEarliestTimeframe returns members ordered from the earliest timeframe; timeframes
+// that cannot be parsed come last
+func byEarliestTimeframe(predictions []models.Prediction, members []int) []int {
+	ordered := slices.Clone(members)
+	days := func(m int) float64 {
+		tf, err := models.ParseTimeframe(predictions[m].Timeframe)
+		if err != nil {
+			return -1
+		}
+		return tf.Days()
+	}
+	sort.SliceStable(ordered, func(a, b int) bool {
+		dayA, dayB := days(ordered[a]), days(ordered[b])
+		if (dayA < 0) != (dayB < 0) {
+			return dayB < 0
+		}
+		return dayA < dayB
+	})
+	return ordered
+}
+
+// mergePredictions folds the predictions at members into the first one, joining the
+// distinct descriptions and taking the union of the sectors, tickers, sources and parents
+func mergePredictions(predictions []models.Prediction, members []int) models.Prediction {
+	kept := predictions[members[0]]
+	descriptions := []string{strings.TrimSpace(kept.Description)}
+	kept.Sectors = slices.Clone(kept.Sectors)
+	kept.Tickers = slices.Clone(kept.Tickers)
+	kept.Sources = slices.Clone(kept.Sources)
+	kept.Parents = slices.Clone(kept.Parents)
+	for _, m := range members[1:] {
+		duplicate := predictions[m]
+		if d := strings.TrimSpace(duplicate.Description); !slices.Contains(descriptions, d) {
+			descriptions = append(descriptions, d)
+		}
+		kept.Sectors = union(kept.Sectors, duplicate.Sectors)
+		kept.Tickers = union(kept.Tickers, duplicate.Tickers)
+		kept.Sources = union(kept.Sources, duplicate.Sources)
+		kept.Parents = union(kept.Parents, duplicate.Parents)
+	}
+	kept.Description = strings.Join(descriptions, " ")
+	return kept
+}
+
+// relink points the parents of predictions at the IDs the merged duplicates were renamed
+// to, dropping repeated parents and predictions made their own parent
+func relink(predictions []models.Prediction, renamed map[string]string) {
+	for i := range predictions {
+		var parents []string
+		for _, parent := range predictions[i].Parents {
+			if to, ok := renamed[parent]; ok {
+				parent = to
+			}
+			if parent != predictions[i].ID && !slices.Contains(parents, parent) {
+				parents = append(parents, parent)
+			}
+		}
+		predictions[i].Parents = parents
+	}
+}
+
+// union appends the values of b missing from a, ignoring case
+func union(a, b []string) []string {
+	for _, v := range b {
+		if !slices.ContainsFunc(a, func(x string) bool { return strings.EqualFold(x, v) }) {
+			a = append(a, v)
+		}
+	}
+	return a
+}
@@ -0,0 +1,121 @@
+package llm_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+)
+
+// fakeEmbedder returns fixed vectors, or err
+type fakeEmbedder struct {
+	vectors map[string][]float64
+	err     error
+}
+
+func (e fakeEmbedder) Embed(texts []string) ([][]float64, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i] = e.vectors[text]
+	}
+	return embeddings, nil
+}
+
+func TestDedupePredictions(t *testing.T) {
+	var critiquePrompt string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			critiquePrompt = body
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "p2", "timeframe": "2 weeks", "description": "Oil prices rise sharply on supply fears", "impact": "Producers gain", "confidence": 0.7, "critique": "Likely"}, {"id": "p3", "parents": ["p2"], "timeframe": "3 months", "description": "Airlines cut capacity", "impact": "Airlines fall", "confidence": 0.5, "critique": "Plausible"}]}`
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"id": "p1", "timeframe": "1 month", "description": "Oil prices rise sharply", "impact": "Energy rallies", "sectors": ["Energy"]}, {"id": "p2", "timeframe": "2 weeks", "description": "Oil prices rise sharply on supply fears", "impact": "Producers gain", "sectors": ["Materials"]}, {"id": "p3", "parents": ["p1"], "timeframe": "3 months", "description": "Airlines cut capacity", "impact": "Airlines fall"}]}`
+	})
+
+	result, err := llm.GenerateCritiquedResponse("x", client, llm.WithDedupe(0.5))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if strings.Contains(critiquePrompt, `\"id\":\"p1\"`) || !strings.Contains(critiquePrompt, `\"parents\":[\"p2\"]`) {
+		t.Errorf("Expected p1 merged into p2 and p3 relinked in the critique prompt: %s", critiquePrompt)
+	}
+	if !strings.Contains(critiquePrompt, "Oil prices rise sharply on supply fears Oil prices rise sharply") || !strings.Contains(critiquePrompt, `[\"Materials\",\"Energy\"]`) {
+		t.Errorf("Expected the merged prediction to combine descriptions and sectors: %s", critiquePrompt)
+	}
+	if len(result.Merges) != 1 {
+		t.Fatalf("Expected one merge, got %+v", result.Merges)
+	}
+	merge := result.Merges[0]
+	if merge.Timeframe != "2 weeks" || len(merge.Duplicates) != 1 || merge.Duplicates[0].Timeframe != "1 month" || merge.Duplicates[0].Similarity < 0.5 {
+		t.Errorf("Expected the 1 month duplicate merged into the 2 week prediction, got %+v", merge)
+	}
+
+	// Unrelated wording with identical embeddings is merged once embeddings are averaged in
+	embedder := fakeEmbedder{vectors: map[string][]float64{
+		"Oil prices rise sharply":                 {1, 0},
+		"Oil prices rise sharply on supply fears": {1, 0},
+		"Airlines cut capacity":                   {1, 0},
+	}}
+	result, err = llm.GenerateCritiquedResponse("x", client, llm.WithDedupe(0.5), llm.WithEmbedder(embedder))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(result.Merges) != 1 || len(result.Merges[0].Duplicates) != 2 {
+		t.Errorf("Expected all three predictions merged by their embeddings, got %+v", result.Merges)
+	}
+	if strings.Contains(critiquePrompt, `\"parents\":[`) {
+		t.Errorf("Expected the self-referencing parent dropped: %s", critiquePrompt)
+	}
+
+	embedder.err = errors.New("embeddings unavailable")
+	result, err = llm.GenerateCritiquedResponse("x", client, llm.WithDedupe(0.5), llm.WithEmbedder(embedder))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(result.Merges) != 1 || len(result.Merges[0].Duplicates) != 1 {
+		t.Errorf("Expected a lexical merge when embedding fails, got %+v", result.Merges)
+	}
+
+	result, err = llm.GenerateCritiquedResponse("x", client)
+	if err != nil || len(result.Merges) != 0 {
+		t.Errorf("Expected no merging without WithDedupe, got %+v, %v", result, err)
+	}
+}
+
+func TestDedupeKeepsGraphAndDirections(t *testing.T) {
+	var critiquePrompts []string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			critiquePrompts = append(critiquePrompts, body)
+			confidence := 0.5 + 0.1*float64(len(critiquePrompts))
+			return http.StatusOK, fmt.Sprintf(`{"original_prompt": "x", "predictions": [{"id": "oil", "timeframe": "1 month", "description": "Oil prices rise sharply", "impact": "Energy rallies", "confidence": %v, "critique": "Likely"}]}`, confidence)
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Oil prices rise sharply", "impact": "Energy rallies", "direction": "bullish"}, {"id": "oil", "timeframe": "2 months", "description": "Oil prices rise sharply on supply fears", "impact": "Producers gain", "direction": "bullish"}, {"id": "air", "parents": ["oil"], "timeframe": "3 months", "description": "Airlines cut capacity", "impact": "Airlines fall"}, {"timeframe": "1 month", "description": "Oil prices rise sharply", "impact": "Energy slumps", "direction": "bearish"}]}`
+	})
+
+	result, err := llm.GenerateCritiquedResponse("x", client, llm.WithDedupe(0.5), llm.WithDebateRounds(1))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if len(critiquePrompts) != 2 {
+		t.Fatalf("Expected a critique and a debate round, got %d critiques", len(critiquePrompts))
+	}
+	// The kept prediction takes the duplicate's id, so airlines still follow from it
+	first := critiquePrompts[0]
+	if !strings.Contains(first, `\"id\":\"oil\",\"timeframe\":\"1 month\"`) || !strings.Contains(first, `\"parents\":[\"oil\"]`) {
+		t.Errorf("Expected the merged prediction to keep the oil id and its child, got: %s", first)
+	}
+	if !strings.Contains(first, "Energy slumps") {
+		t.Errorf("Expected the bearish near-duplicate kept apart, got: %s", first)
+	}
+	// The revision merges the same duplicates again, but only the first merge is reported
+	if len(result.Merges) != 1 || len(result.Merges[0].Duplicates) != 1 || result.Merges[0].Duplicates[0].Timeframe != "2 months" {
+		t.Errorf("Expected the one merge made before the critique, got %+v", result.Merges)
+	}
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Embedder turns texts into embedding vectors
+type Embedder interface {
+	Embed(texts []string) ([][]float64, error)
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint such as
+// https://api.openai.com/v1/embeddings
+type HTTPEmbedder struct {
+	httpClient *http.Client
+	backend    Backend
+}
+
+// NewEmbedder creates an embedder calling the model of b at its URL
+func NewEmbedder(httpClient *http.Client, b Backend) *HTTPEmbedder {
+	return &HTTPEmbedder{httpClient: httpClient, backend: b}
+}
+
+// Embed returns the embedding of each text, in order
+func (e *HTTPEmbedder) Embed(texts []string) ([][]float64, error) {
+	requestBody, err := json.Marshal(map[string]interface{}{"model": e.backend.Model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", e.backend.BaseURL, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.backend.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.backend.APIKey)
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+	}
+
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float64, len(texts))
+	for _, d := range response.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = d.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("no embedding returned for text %d", i)
+		}
+	}
+	return embeddings, nil
+}
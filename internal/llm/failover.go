@@ -0,0 +1,177 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Backend is a model served by an OpenAI-compatible chat completions endpoint
+type Backend struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	// APIKey is sent as a bearer token; endpoints that need none may leave it empty
+	APIKey string
+	// KnowledgeCutoff is the end of the model's training data; zero uses the cutoff of
+	// known models
+	KnowledgeCutoff time.Time
+}
+
+// Name identifies the backend as provider/model
+func (b Backend) Name() string {
+	return b.Provider + "/" + b.Model
+}
+
+// WithBackends sets the backends the client calls, in priority order. A call that fails
+// on one backend is retried on the next before the failure is returned.
+func WithBackends(backends ...Backend) ClientOption {
+	return func(c *Client) {
+		c.backends = nil
+		for _, b := range backends {
+			c.backends = append(c.backends, &backend{Backend: b})
+		}
+	}
+}
+
+// WithCircuitBreaker sets how many consecutive failures open the circuit of a backend and
+// how long it stays open before a single probe call may close it again. A failures of 0
+// disables the breakers. The default is 5 failures and 30 seconds.
+func WithCircuitBreaker(failures int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.breakerFailures = failures
+		c.breakerCooldown = cooldown
+	}
+}
+
+// backend is a Backend with the circuit breaker guarding it
+type backend struct {
+	Backend
+	breaker *breaker
+}
+
+// send posts the payload to the first backend whose circuit lets it through and fails over
+// to the following ones until a call succeeds. It returns the response and the name of the
+// backend that produced it. When every circuit is open all backends are tried anyway, so
+// the breakers only ever reorder calls and never fail them on their own.
+func (c *Client) send(sc scope, requestPayload map[string]interface{}) (string, string, error) {
+	candidates := c.available()
+	response, served, tried, errs := c.failover(sc, candidates, len(candidates) == 0, requestPayload)
+	if served == "" && len(tried) == 0 {
+		// Every circuit is open, or concurrent calls reserved the probes in the meantime
+		response, served, tried, errs = c.failover(sc, c.backends, true, requestPayload)
+	}
+	if served != "" {
+		return response, served, nil
+	}
+	if len(errs) == 1 {
+		return "", "", errs[0]
+	}
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s: %w", tried[i].Name(), err)
+	}
+	return "", "", errors.Join(errs...)
+}
+
+// failover calls candidates in order until one succeeds, returning its response and name
+// or the backends tried and their errors. Unless forced, a backend is skipped when its
+// circuit does not let the call through; the probe of a half-open circuit is only reserved
+// right before calling it, so a call answered by an earlier backend leaves it free.
+func (c *Client) failover(sc scope, candidates []*backend, forced bool, requestPayload map[string]interface{}) (string, string, []*backend, []error) {
+	var tried []*backend
+	var errs []error
+	for _, b := range candidates {
+		if !forced && !b.breaker.allow() {
+			continue
+		}
+		if len(errs) > 0 {
+			sc.log.Warn("Failing over to next LLM backend", "backend", b.Name(), "error", errs[len(errs)-1])
+		}
+		response, err := c.sendTo(sc, b, requestPayload)
+		if err == nil {
+			b.breaker.success()
+			return response, b.Name(), nil, nil
+		}
+		if b.breaker.failure() {
+			sc.log.Warn("LLM backend circuit opened", "backend", b.Name(), "cooldown", b.breaker.cooldown)
+		}
+		tried = append(tried, b)
+		errs = append(errs, err)
+	}
+	return "", "", tried, errs
+}
+
+// available returns the backends whose circuit would let a call through, in priority
+// order, without reserving any probe
+func (c *Client) available() []*backend {
+	var available []*backend
+	for _, b := range c.backends {
+		if b.breaker.ready() {
+			available = append(available, b)
+		}
+	}
+	return available
+}
+
+// breaker is a circuit breaker: it opens after threshold consecutive failures and, once
+// cooldown has passed, lets a single probe call through that closes it on success
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	// openedAt is zero while the circuit is closed
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// ready reports whether allow would let a call through, without reserving the probe
+func (b *breaker) ready() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold <= 0 || b.openedAt.IsZero() || (!b.probing && time.Since(b.openedAt) >= b.cooldown)
+}
+
+// allow reports whether a call may be made, reserving the probe of a half-open circuit
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold <= 0 || b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success closes the circuit
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.probing = false
+}
+
+// failure records a failed call and reports whether it opened the circuit
+func (b *breaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.threshold <= 0 {
+		return false
+	}
+	wasOpen := !b.openedAt.IsZero()
+	if b.probing || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.probing = false
+	}
+	return !wasOpen && !b.openedAt.IsZero()
+}
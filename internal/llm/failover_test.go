@@ -0,0 +1,143 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+)
+
+func TestFailoverToBackupBackend(t *testing.T) {
+	llmtest.Setup(t)
+	primaryDown := true
+	var primaryCalls int
+	var backupModels []string
+	client := &http.Client{
+		Transport: llmtest.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			bodyBytes, _ := io.ReadAll(req.Body)
+			if req.URL.Host == "primary.example" {
+				primaryCalls++
+				if primaryDown {
+					return llmtest.Response(http.StatusServiceUnavailable, "unavailable"), nil
+				}
+			} else {
+				var payload struct {
+					Model string `json:"model"`
+				}
+				json.Unmarshal(bodyBytes, &payload)
+				backupModels = append(backupModels, payload.Model)
+				if req.Header.Get("Authorization") != "Bearer backup-key" {
+					t.Errorf("Expected the backup key, got %q", req.Header.Get("Authorization"))
+				}
+			}
+			return llmtest.Response(llmtest.Answer(string(bodyBytes))), nil
+		}),
+	}
+
+	llmClient, err := llm.NewClient(client,
+		llm.WithBackends(
+			llm.Backend{Provider: "primary", Model: "model-a", BaseURL: "https://primary.example/v1/chat/completions", APIKey: "primary-key"},
+			llm.Backend{Provider: "backup", Model: "model-b", BaseURL: "https://backup.example/v1/chat/completions", APIKey: "backup-key"},
+		),
+		llm.WithCircuitBreaker(1, 50*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+	result, err := llm.GenerateCritiquedResponse("test event", llmClient)
+	if err != nil {
+		t.Fatalf("Expected the backup backend to answer, got error: %v", err)
+	}
+	if result.Backend != "backup/model-b" {
+		t.Errorf("Expected the result to record backup/model-b, got %q", result.Backend)
+	}
+	if primaryCalls != 1 {
+		t.Errorf("Expected the open circuit to skip the primary after its first failure, got %d calls", primaryCalls)
+	}
+	if len(backupModels) != 2 || backupModels[0] != "model-b" {
+		t.Errorf("Expected both stages sent to model-b, got %v", backupModels)
+	}
+
+	// Once the cooldown has passed a probe call closes the circuit again
+	primaryDown = false
+	time.Sleep(60 * time.Millisecond)
+	result, err = llm.GenerateCritiquedResponse("test event", llmClient)
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if result.Backend != "primary/model-a" || primaryCalls != 3 {
+		t.Errorf("Expected the recovered primary to answer both stages, got %q after %d calls", result.Backend, primaryCalls)
+	}
+}
+
+func TestBackupRecoversWhilePrimaryIsUp(t *testing.T) {
+	llmtest.Setup(t)
+	down := map[string]bool{"a.example": true, "b.example": true}
+	calls := make(map[string]int)
+	client := &http.Client{
+		Transport: llmtest.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			calls[req.URL.Host]++
+			if down[req.URL.Host] {
+				return llmtest.Response(http.StatusServiceUnavailable, "unavailable"), nil
+			}
+			return llmtest.Response(http.StatusOK, `{"choices": [{"message": {"content": "ok"}}]}`), nil
+		}),
+	}
+	llmClient, err := llm.NewClient(client,
+		llm.WithBackends(
+			llm.Backend{Provider: "a", Model: "m", BaseURL: "https://a.example"},
+			llm.Backend{Provider: "b", Model: "m", BaseURL: "https://b.example"},
+		),
+		llm.WithCircuitBreaker(1, 20*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+	if _, err := llmClient.CallLLM("hi"); err == nil {
+		t.Fatal("Expected both backends to fail")
+	}
+
+	// Both circuits are half-open: the primary's probe answers, which must leave the
+	// backup's probe free
+	down = map[string]bool{}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := llmClient.CallLLM("hi"); err != nil {
+		t.Fatalf("Expected the recovered primary to answer, got %v", err)
+	}
+
+	down["a.example"] = true
+	calls = make(map[string]int)
+	if _, err := llmClient.CallLLM("hi"); err != nil {
+		t.Fatalf("Expected the backup to answer once the primary fails again, got %v (calls=%v)", err, calls)
+	}
+	if calls["a.example"] != 1 || calls["b.example"] != 1 {
+		t.Errorf("Expected one call to each backend, got %v", calls)
+	}
+}
+
+func TestFailoverReportsEveryBackend(t *testing.T) {
+	llmtest.Setup(t)
+	client := &http.Client{
+		Transport: llmtest.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return llmtest.Response(http.StatusBadGateway, "bad gateway from "+req.URL.Host), nil
+		}),
+	}
+	llmClient, err := llm.NewClient(client, llm.WithBackends(
+		llm.Backend{Provider: "a", Model: "m", BaseURL: "https://a.example"},
+		llm.Backend{Provider: "b", Model: "m", BaseURL: "https://b.example"},
+	))
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+	_, err = llmClient.CallLLM("hi")
+	var apiErr *llm.APIError
+	if err == nil || !errors.As(err, &apiErr) || !strings.Contains(err.Error(), "a/m: API returned status 502: bad gateway from a.example") || !strings.Contains(err.Error(), "b/m: API returned status 502: bad gateway from b.example") {
+		t.Errorf("Expected the failure of both backends, got: %v", err)
+	}
+}
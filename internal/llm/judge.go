@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"nostradamus/internal/models"
+)
+
+// Judge asks the LLM to score how well critiqued predictions made for event anticipated
+// what actually happened, as described by outcome
+func Judge(client *Client, event, outcome string, result *models.CritiquedResponse) (*models.Judgement, error) {
+	predictions, err := json.Marshal(result.Predictions)
+	if err != nil {
+		return nil, err
+	}
+	return callWithRetry(scope{log: client.log}, client.callLLM, "judge", judgePrompt(event, outcome, string(predictions)), func(j *models.Judgement) error {
+		return j.Validate()
+	})
+}
+
+// judgePrompt builds the LLM-as-judge prompt comparing predictions with what happened
+func judgePrompt(event, outcome, predictions string) string {
+	return fmt.Sprintf("You are judging stock market predictions made right after the event: %q. What actually happened in the markets afterwards: %s. Score how well the predictions anticipated this, rewarding correct sectors, directions and timeframes and confidences that match how things turned out, and penalising confident predictions that did not happen. Return JSON with \"score\", a float between 0 and 1, and \"rationale\", two to three sentences explaining the score. Predictions: %s", event, outcome, predictions)
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"nostradamus/internal/metrics"
+	"nostradamus/internal/models"
+)
+
+// Metrics holds the instruments pipeline runs and LLM calls report to. A nil
+// *Metrics records nothing.
+type Metrics struct {
+	calls              *metrics.Counter
+	callDuration       *metrics.Histogram
+	tokens             *metrics.Counter
+	retries            *metrics.Counter
+	validationFailures *metrics.Counter
+	runDuration        *metrics.Histogram
+	rateLimitWait      *metrics.Histogram
+}
+
+// NewMetrics registers the pipeline instruments on registry
+func NewMetrics(registry *metrics.Registry) *Metrics {
+	return &Metrics{
+		calls: registry.NewCounter("nostradamus_llm_calls_total",
+			"LLM API calls by provider, model and HTTP status (\"error\" when no response was received).",
+			"provider", "model", "status"),
+		callDuration: registry.NewHistogram("nostradamus_llm_call_duration_seconds",
+			"Latency of LLM API calls.", metrics.DefaultBuckets, "provider", "model"),
+		tokens: registry.NewCounter("nostradamus_llm_tokens_total",
+			"Tokens reported by the LLM API by provider, model and type (prompt or completion).",
+			"provider", "model", "type"),
+		retries: registry.NewCounter("nostradamus_retries_total",
+			"Failed attempts that were retried, by stage and failure reason.", "stage", "reason"),
+		validationFailures: registry.NewCounter("nostradamus_validation_failures_total",
+			"LLM responses rejected by the output structure validation, by stage and rule.", "stage", "rule"),
+		runDuration: registry.NewHistogram("nostradamus_run_duration_seconds",
+			"End-to-end latency of prediction runs by outcome.", metrics.DefaultBuckets, "status"),
+		rateLimitWait: registry.NewHistogram("nostradamus_rate_limit_wait_seconds",
+			"Time LLM calls spent waiting on client rate limits, by limit (requests, tokens or concurrency).",
+			metrics.DefaultBuckets, "provider", "model", "limit"),
+	}
+}
+
+// observeCall records an LLM API call; status is 0 when no response was received
+func (m *Metrics) observeCall(provider, model string, status int, duration time.Duration, usage *tokenUsage) {
+	if m == nil {
+		return
+	}
+	statusLabel := "error"
+	if status != 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	m.calls.Inc(provider, model, statusLabel)
+	m.callDuration.Observe(duration.Seconds(), provider, model)
+	if usage != nil {
+		m.tokens.Add(float64(usage.PromptTokens), provider, model, "prompt")
+		m.tokens.Add(float64(usage.CompletionTokens), provider, model, "completion")
+	}
+}
+
+// observeWait records the time a call spent waiting on each configured rate limit
+func (m *Metrics) observeWait(provider, model string, waited waits) {
+	if m == nil {
+		return
+	}
+	for limit, duration := range waited {
+		m.rateLimitWait.Observe(duration.Seconds(), provider, model, limit)
+	}
+}
+
+// observeFailure records a failed attempt of stage and whether it will be retried
+func (m *Metrics) observeFailure(stage string, err error, retried bool) {
+	if m == nil {
+		return
+	}
+	reason := "api_error"
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		reason = "validation"
+		m.validationFailures.Inc(stage, validationErr.Rule)
+	}
+	if retried {
+		m.retries.Inc(stage, reason)
+	}
+}
+
+// observeRun records the end-to-end latency of a run
+func (m *Metrics) observeRun(duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	m.runDuration.Observe(duration.Seconds(), status)
+}
@@ -0,0 +1,55 @@
+package llm_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/metrics"
+)
+
+func TestPipelineMetrics(t *testing.T) {
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			return http.StatusOK, `{"choices": [{"message": {"content": "{\"original_prompt\": \"test event\", \"predictions\": [{\"timeframe\": \"1 week\", \"description\": \"Event A\", \"impact\": \"Market volatility\"}]}"}}], "usage": {"prompt_tokens": 120, "completion_tokens": 80, "total_tokens": 200}}`
+		}
+		critiqueCount++
+		switch critiqueCount {
+		case 1:
+			return http.StatusInternalServerError, "Internal Server Error"
+		case 2:
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "confidence": 1.5, "critique": "Certain"}]}`
+		}
+		return http.StatusOK, llmtest.Critique
+	})
+
+	registry := metrics.NewRegistry()
+	if _, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithMetrics(llm.NewMetrics(registry))); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	exposition := buf.String()
+	for _, expected := range []string{
+		"# TYPE nostradamus_llm_calls_total counter\n",
+		`nostradamus_llm_calls_total{provider="openai",model="o1-mini",status="200"} 3`,
+		`nostradamus_llm_calls_total{provider="openai",model="o1-mini",status="500"} 1`,
+		`nostradamus_llm_tokens_total{provider="openai",model="o1-mini",type="prompt"} 120`,
+		`nostradamus_retries_total{stage="critique",reason="api_error"} 1`,
+		`nostradamus_retries_total{stage="critique",reason="validation"} 1`,
+		`nostradamus_validation_failures_total{stage="critique",rule="confidence_range"} 1`,
+		`nostradamus_run_duration_seconds_bucket{status="success",le="+Inf"} 1`,
+		`nostradamus_run_duration_seconds_count{status="success"} 1`,
+	} {
+		if !strings.Contains(exposition, expected) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", expected, exposition)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"log/slog"
+	"time"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/tracing"
+)
+
+// options holds the settings of a prediction pipeline run
+type options struct {
+	debateRounds    int
+	debateTolerance float64
+
+	samples           int
+	sampleTemperature float64
+	clusterThreshold  float64
+
+	tickers models.TickerSet
+	asOf    time.Time
+
+	retriever Retriever
+	topK      int
+
+	analogs     AnalogFinder
+	analogCount int
+
+	coherence string
+
+	dedupe          bool
+	dedupeThreshold float64
+	embedder        Embedder
+
+	prompts    *Prompts
+	persona    string
+	calibrator Calibrator
+
+	logger  *slog.Logger
+	tracer  *tracing.Tracer
+	metrics *Metrics
+}
+
+// Option configures a prediction pipeline run
+type Option func(*options)
+
+// WithDebateRounds sets the maximum number of predictor/critic refinement rounds run after the first critique
+func WithDebateRounds(rounds int) Option {
+	return func(o *options) {
+		o.debateRounds = rounds
+	}
+}
+
+// WithDebateTolerance sets the largest confidence shift between two rounds at which the debate is considered stable
+func WithDebateTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.debateTolerance = tolerance
+	}
+}
+
+// WithSamples sets how many prediction sets are sampled and clustered before the critique
+func WithSamples(samples int) Option {
+	return func(o *options) {
+		o.samples = samples
+	}
+}
+
+// WithSampleTemperature sets the temperature used when sampling several prediction sets
+func WithSampleTemperature(temperature float64) Option {
+	return func(o *options) {
+		o.sampleTemperature = temperature
+	}
+}
+
+// WithClusterThreshold sets the lexical similarity above which sampled predictions are treated as the same prediction
+func WithClusterThreshold(threshold float64) Option {
+	return func(o *options) {
+		o.clusterThreshold = threshold
+	}
+}
+
+// WithTickers restricts the tickers predictions may reference to the given set
+func WithTickers(tickers models.TickerSet) Option {
+	return func(o *options) {
+		o.tickers = tickers
+	}
+}
+
+// WithAsOf sets the date predictions are made on, which the prompts anchor timeframes
+// to; it defaults to now
+func WithAsOf(asOf time.Time) Option {
+	return func(o *options) {
+		o.asOf = asOf
+	}
+}
+
+// WithPrompts overrides the built-in prompts with the templates in prompts
+func WithPrompts(prompts *Prompts) Option {
+	return func(o *options) {
+		o.prompts = prompts
+	}
+}
+
+// WithPersona names the critic persona, typically the one written into custom critique
+// templates, so its confidences can be calibrated separately
+func WithPersona(persona string) Option {
+	return func(o *options) {
+		o.persona = persona
+	}
+}
+
+// WithLogger sets the logger the run's records are written to
+func WithLogger(log *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = log
+	}
+}
+
+// WithTracer traces the run, its stages, attempts and HTTP calls with tracer
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithMetrics records the run and its LLM calls on m
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// newOptions applies opts over the defaults
+func newOptions(opts []Option) options {
+	o := options{
+		debateTolerance:   0.05,
+		samples:           1,
+		sampleTemperature: 1,
+		clusterThreshold:  0.5,
+		asOf:              time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/tracing"
+)
+
+// pipeline holds the state shared by the stages of a single run
+type pipeline struct {
+	input  string
+	client *Client
+	opts   options
+	// root carries the run_id on every record and the run span
+	root scope
+	// passages were retrieved for the input and may be cited as sources
+	passages []models.Passage
+	sources  models.PassageSet
+	// analogs are the past events similar to the input shown to the critic
+	analogs []models.Analog
+	// merges are the near-duplicate predictions merged before the first critique
+	merges []models.Merge
+}
+
+// scope is where an operation reports to: a logger carrying its correlation attributes,
+// the span it is traced under and the metrics it records
+type scope struct {
+	log     *slog.Logger
+	span    *tracing.Span
+	metrics *Metrics
+}
+
+// child returns the scope of an operation nested in s, with attrs given as key-value
+// pairs added to both its log records and its span
+func (s scope) child(name string, attrs ...any) scope {
+	child := scope{log: s.log, span: s.span.Child(name), metrics: s.metrics}
+	if len(attrs) > 0 {
+		child.log = s.log.With(attrs...)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if key, ok := attrs[i].(string); ok {
+			child.span.SetAttribute(key, attrs[i+1])
+		}
+	}
+	return child
+}
+
+// newPipeline prepares a run for input with its own run ID. It logs to the logger set with
+// WithLogger, falling back to the client's logger, and traces to the tracer set with WithTracer.
+func newPipeline(input string, client *Client, o options) *pipeline {
+	log := o.logger
+	if log == nil {
+		log = client.log
+	}
+	runID := newRunID()
+	span := o.tracer.Start("run")
+	span.SetAttribute("run_id", runID)
+	return &pipeline{
+		input:  input,
+		client: client,
+		opts:   o,
+		root:   scope{log: log.With("run_id", runID), span: span, metrics: o.metrics},
+	}
+}
+
+// stageLog returns the run logger tagged with stage
+func (p *pipeline) stageLog(stage string) *slog.Logger {
+	return p.root.log.With("stage", stage)
+}
+
+// newRunID returns a random identifier correlating the log records of a run
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
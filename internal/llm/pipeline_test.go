@@ -0,0 +1,85 @@
+package llm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/logger"
+)
+
+func TestLLMLogsCarryCorrelationIDs(t *testing.T) {
+	var logs bytes.Buffer
+	originalLogger := logger.DefaultLogger
+	logger.DefaultLogger = logger.NewWithOptions(logger.Options{Level: slog.LevelDebug, Format: "json", Output: &logs})
+	defer func() { logger.DefaultLogger = originalLogger }()
+
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			if critiqueCount++; critiqueCount == 1 {
+				return http.StatusOK, "invalid json"
+			}
+		}
+		return llmtest.Answer(body)
+	})
+	if _, err := llm.GenerateCritiquedPredictions("test event", client); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+
+	runIDs := make(map[string]bool)
+	foundInvalid := false
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON log record, got %q: %v", line, err)
+		}
+		runID, _ := record["run_id"].(string)
+		if runID == "" {
+			t.Errorf("Expected run_id on every record, got: %s", line)
+		}
+		runIDs[runID] = true
+		if record["msg"] == "Invalid JSON in LLM response" {
+			foundInvalid = true
+			if record["stage"] != "critique" || record["attempt"] != float64(1) {
+				t.Errorf("Expected stage and attempt on the retry record, got: %s", line)
+			}
+		}
+	}
+	if len(runIDs) != 1 {
+		t.Errorf("Expected a single run_id for the run, got: %v", runIDs)
+	}
+	if !foundInvalid {
+		t.Errorf("Expected a record for the invalid critique response, got: %s", logs.String())
+	}
+}
+
+func TestInjectedLoggers(t *testing.T) {
+	var clientLogs, runLogs bytes.Buffer
+	clientLogger := slog.New(slog.NewTextHandler(&clientLogs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	runLogger := slog.New(slog.NewTextHandler(&runLogs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	client := llmtest.NewClient(t, llmtest.Answer, llm.WithClientLogger(clientLogger))
+	if _, err := llm.GenerateCritiquedPredictions("test event", client); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(clientLogs.String(), "Starting prediction run") || !strings.Contains(clientLogs.String(), "LLM HTTP request completed") {
+		t.Errorf("Expected run and HTTP records on the client logger, got: %s", clientLogs.String())
+	}
+
+	clientLogs.Reset()
+	if _, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithLogger(runLogger)); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(runLogs.String(), "Starting prediction run") || !strings.Contains(runLogs.String(), "stage=critique") {
+		t.Errorf("Expected run records on the injected logger, got: %s", runLogs.String())
+	}
+	if strings.Contains(clientLogs.String(), "Starting prediction run") {
+		t.Errorf("Expected run records to bypass the client logger, got: %s", clientLogs.String())
+	}
+}
@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// predictionPrompt builds the predictor system prompt for the given event, made as of asOf
+// with the retrieved passages
+func predictionPrompt(input string, asOf time.Time, tickers models.TickerSet, passages []models.Passage) string {
+	return withContext(fmt.Sprintf("You are a predictor of future stock market events. %s Given the event: %q, generate predictions in JSON format. The JSON output must have \"original_prompt\" equal to the input and \"predictions\" be an array with between 1 and 10 items with each item containing \"timeframe\", \"description\", and \"impact\". The timeframe must be given in the format \"X {weeks, months, years}\", where X is the when the preditions will occur. The impact is a short sentence explaining the impact on the market and the industry likely to be impacted. The description is a short paragraph of two to four sentences explaining in more details the prediction. %s %s", asOfInstructions(asOf), input, tagInstructions(tickers), graphInstructions), passageInstructions(passages))
+}
+
+// critiquePrompt builds the critic system prompt for the given predictions JSON, made as of
+// asOf with the retrieved passages and historical analogs
+func critiquePrompt(predictions string, asOf time.Time, passages []models.Passage, analogs []models.Analog) string {
+	return withContext(fmt.Sprintf("You are a knowledgeable investor. %s Critically review the following predictions in JSON format and add two additional fields to each prediction: \"confidence\" (a float between 0 and 1) and \"critique\" (a string explaining why this prediction is likely or not). The critique field is a short paragraph of two to three sentences explaining how you reason about the confidence rating you gave to the event. Keep every other field of each prediction unchanged, including \"id\" and \"parents\". For a prediction with \"parents\", the confidence is the probability that it happens given that all its parents happen. Input predictions: %s", asOfInstructions(asOf), predictions), passageReviewInstructions(passages), analogInstructions(analogs))
+}
+
+// revisionPrompt builds the predictor prompt used to revise predictions after a critique round
+func revisionPrompt(input, critiqued string, asOf time.Time, tickers models.TickerSet, passages []models.Passage) string {
+	return withContext(fmt.Sprintf("You are a predictor of future stock market events revising your own work. %s For the event: %q, a knowledgeable investor reviewed your predictions and gave each one a \"confidence\" (a float between 0 and 1) and a \"critique\". Use this feedback to revise the predictions: you may reword or adjust them, drop the ones the review shows to be unlikely or irrelevant, and add new ones the review suggests were missed. Return JSON with \"original_prompt\" equal to the event and \"predictions\" an array with between 1 and 10 items with each item containing \"timeframe\", \"description\", and \"impact\" but no \"confidence\" or \"critique\". The timeframe must be given in the format \"X {weeks, months, years}\". %s %s Reviewed predictions: %s", asOfInstructions(asOf), input, tagInstructions(tickers), graphInstructions, critiqued), passageInstructions(passages))
+}
+
+// withContext appends the non-empty passage and analog instructions to prompt
+func withContext(prompt string, instructions ...string) string {
+	for _, i := range instructions {
+		if i != "" {
+			prompt += "\n\n" + i
+		}
+	}
+	return prompt
+}
+
+// asOfInstructions tells the model the date predictions are made on, so timeframes are
+// anchored to it and nothing that happened later is used
+func asOfInstructions(asOf time.Time) string {
+	return fmt.Sprintf("Today is %s: count every timeframe from this date and reason only from what was known on it.", asOf.Format("2006-01-02"))
+}
+
+// graphInstructions asks the predictor to chain predictions into a causal graph
+const graphInstructions = "Predictions may follow from one another, as sanctions raise energy prices which squeeze airline margins. Give each item an \"id\" such as \"p1\" and, when it is a consequence of other predictions, \"parents\", an array of their ids; a prediction must never be its own ancestor."
+
+// tagInstructions describes the structured tag fields the predictor must add to each prediction
+func tagInstructions(tickers models.TickerSet) string {
+	instructions := fmt.Sprintf("Each item must also contain \"sectors\", an array of the affected GICS sectors taken from [%s], \"direction\", one of [%s], and \"magnitude\", one of [%s].",
+		strings.Join(models.Sectors, ", "), strings.Join(models.Directions, ", "), strings.Join(models.Magnitudes, ", "))
+	if len(tickers) == 0 {
+		return instructions + " Items may contain \"tickers\", an array of the stock ticker symbols most affected."
+	}
+	symbols := make([]string, 0, len(tickers))
+	for t := range tickers {
+		symbols = append(symbols, t)
+	}
+	sort.Strings(symbols)
+	return instructions + fmt.Sprintf(" Items may contain \"tickers\", an array of the affected stock ticker symbols taken only from [%s].", strings.Join(symbols, ", "))
+}
+
+// Prompts overrides the built-in prompts with text/template templates executed with a
+// PromptData. A nil template keeps the built-in prompt.
+type Prompts struct {
+	Prediction *template.Template
+	Critique   *template.Template
+	Revision   *template.Template
+}
+
+// PromptData is the data prompt templates are executed with
+type PromptData struct {
+	// Input is the event predictions are made for
+	Input string
+	// AsOf is the date predictions are made on, as YYYY-MM-DD
+	AsOf string
+	// Tags are the built-in instructions for the sectors, direction, magnitude and tickers fields
+	Tags string
+	// Graph is the built-in instruction for the "id" and "parents" fields chaining predictions
+	Graph string
+	// Passages are the built-in instructions listing the retrieved passages and asking for
+	// them to be cited in "sources", or reviewed in the critique; empty when none were retrieved
+	Passages string
+	// Analogs are the built-in instructions listing the historical analogs and their observed
+	// reactions, set for the critique only; empty when none were found
+	Analogs string
+	// Predictions is the JSON of the predictions to critique or, when revising, of the
+	// critiqued predictions
+	Predictions string
+}
+
+// LoadPrompts reads the prediction.tmpl, critique.tmpl and revision.tmpl templates found
+// in dir. Missing files keep the built-in prompt, but dir must hold at least one of them.
+func LoadPrompts(dir string) (*Prompts, error) {
+	prompts := &Prompts{}
+	targets := map[string]**template.Template{
+		"prediction": &prompts.Prediction,
+		"critique":   &prompts.Critique,
+		"revision":   &prompts.Revision,
+	}
+	found := false
+	for name, target := range targets {
+		path := filepath.Join(dir, name+".tmpl")
+		text, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := template.New(name).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		// Catch references to unknown fields before a run depends on the template
+		if err := tmpl.Execute(io.Discard, PromptData{}); err != nil {
+			return nil, fmt.Errorf("executing %s: %w", path, err)
+		}
+		*target = tmpl
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no prediction.tmpl, critique.tmpl or revision.tmpl in %s", dir)
+	}
+	return prompts, nil
+}
+
+// lookup returns the template overriding the prompt of stage, or nil
+func (ps *Prompts) lookup(stage string) *template.Template {
+	if ps == nil {
+		return nil
+	}
+	switch stage {
+	case "prediction":
+		return ps.Prediction
+	case "critique":
+		return ps.Critique
+	case "revision":
+		return ps.Revision
+	}
+	return nil
+}
+
+// predictionPrompt builds the predictor prompt of the run
+func (p *pipeline) predictionPrompt() string {
+	return p.renderPrompt("prediction", p.promptData("", passageInstructions(p.passages)), func() string {
+		return predictionPrompt(p.input, p.opts.asOf, p.opts.tickers, p.passages)
+	})
+}
+
+// critiquePrompt builds the critic prompt for the given predictions JSON
+func (p *pipeline) critiquePrompt(predictions string) string {
+	data := p.promptData(predictions, passageReviewInstructions(p.passages))
+	data.Analogs = analogInstructions(p.analogs)
+	return p.renderPrompt("critique", data, func() string {
+		return critiquePrompt(predictions, p.opts.asOf, p.passages, p.analogs)
+	})
+}
+
+// revisionPrompt builds the predictor prompt revising the given critiqued predictions JSON
+func (p *pipeline) revisionPrompt(critiqued string) string {
+	return p.renderPrompt("revision", p.promptData(critiqued, passageInstructions(p.passages)), func() string {
+		return revisionPrompt(p.input, critiqued, p.opts.asOf, p.opts.tickers, p.passages)
+	})
+}
+
+// promptData returns the template data of the run with the given predictions JSON and
+// passage instructions
+func (p *pipeline) promptData(predictions, passages string) PromptData {
+	return PromptData{
+		Input:       p.input,
+		AsOf:        p.opts.asOf.Format("2006-01-02"),
+		Tags:        tagInstructions(p.opts.tickers),
+		Graph:       graphInstructions,
+		Passages:    passages,
+		Predictions: predictions,
+	}
+}
+
+// renderPrompt executes the template overriding the prompt of stage, falling back to the
+// built-in prompt when there is none or it fails
+func (p *pipeline) renderPrompt(stage string, data PromptData, builtin func() string) string {
+	tmpl := p.opts.prompts.lookup(stage)
+	if tmpl == nil {
+		return builtin()
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		p.root.log.Error("Prompt template failed, using the built-in prompt", "stage", stage, "error", err)
+		return builtin()
+	}
+	return b.String()
+}
@@ -0,0 +1,58 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+)
+
+func TestPromptTemplates(t *testing.T) {
+	dir := t.TempDir()
+	template := "Critically review, as a cautious analyst of {{.Input}}, these predictions: {{.Predictions}}"
+	if err := os.WriteFile(dir+"/critique.tmpl", []byte(template), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	prompts, err := llm.LoadPrompts(dir)
+	if err != nil {
+		t.Fatalf("Failed to load prompts: %v", err)
+	}
+	if prompts.Prediction != nil || prompts.Critique == nil {
+		t.Errorf("Expected only the critique prompt overridden, got %+v", prompts)
+	}
+
+	var critiquePrompt string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		var payload struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.Unmarshal([]byte(body), &payload)
+		if llmtest.IsCritique(body) {
+			critiquePrompt = payload.Messages[0].Content
+			return http.StatusOK, llmtest.Critique
+		}
+		return http.StatusOK, llmtest.Predictions
+	})
+	if _, err := llm.GenerateCritiquedResponse("test event", client, llm.WithPrompts(prompts)); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.HasPrefix(critiquePrompt, "Critically review, as a cautious analyst of test event, these predictions: {") {
+		t.Errorf("Expected the critique template used, got: %s", critiquePrompt)
+	}
+
+	if err := os.WriteFile(dir+"/revision.tmpl", []byte("{{.Unknown}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.LoadPrompts(dir); err == nil {
+		t.Errorf("Expected a template referencing an unknown field to be rejected")
+	}
+	if _, err := llm.LoadPrompts(t.TempDir()); err == nil {
+		t.Errorf("Expected an empty prompts directory to be rejected")
+	}
+}
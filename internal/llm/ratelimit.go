@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"nostradamus/internal/ratelimit"
+)
+
+// RateLimit caps the calls a client makes to a provider or model. Zero fields are unlimited.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	// MaxConcurrent is the largest number of calls in flight at once
+	MaxConcurrent int
+}
+
+// WithRateLimit limits the calls made to model at provider, or to every model of provider
+// when model is empty. Limits are shared by every goroutine and run using the client.
+func WithRateLimit(provider, model string, limit RateLimit) ClientOption {
+	return func(c *Client) {
+		if c.limiters == nil {
+			c.limiters = make(map[string]*limiter)
+		}
+		c.limiters[limiterKey(provider, model)] = newLimiter(limit)
+	}
+}
+
+// limiter enforces a RateLimit; nil limits are unlimited
+type limiter struct {
+	requests   *ratelimit.Bucket
+	tokens     *ratelimit.Bucket
+	concurrent *ratelimit.Semaphore
+}
+
+func newLimiter(limit RateLimit) *limiter {
+	l := &limiter{}
+	if limit.RequestsPerMinute > 0 {
+		l.requests = ratelimit.NewBucket(float64(limit.RequestsPerMinute))
+	}
+	if limit.TokensPerMinute > 0 {
+		l.tokens = ratelimit.NewBucket(float64(limit.TokensPerMinute))
+	}
+	if limit.MaxConcurrent > 0 {
+		l.concurrent = ratelimit.NewSemaphore(limit.MaxConcurrent)
+	}
+	return l
+}
+
+// limiterKey identifies the limits of a provider and model
+func limiterKey(provider, model string) string {
+	return provider + "/" + model
+}
+
+// limiterFor returns the limiter for model at provider, falling back to the provider's
+// limiter, or nil when the calls are unlimited
+func (c *Client) limiterFor(provider, model string) *limiter {
+	if l, ok := c.limiters[limiterKey(provider, model)]; ok {
+		return l
+	}
+	return c.limiters[limiterKey(provider, "")]
+}
+
+// waits is the time a call spent waiting on each kind of limit
+type waits map[string]time.Duration
+
+// total returns the time spent waiting across all limits
+func (w waits) total() time.Duration {
+	var total time.Duration
+	for _, d := range w {
+		total += d
+	}
+	return total
+}
+
+// acquire waits until a call estimated to use estimatedTokens may start and returns the
+// time spent waiting on each configured limit. The caller must call release once done.
+func (l *limiter) acquire(estimatedTokens int) waits {
+	w := waits{}
+	if l.concurrent != nil {
+		w["concurrency"] = l.concurrent.Acquire()
+	}
+	if l.requests != nil {
+		w["requests"] = l.requests.Take(1)
+	}
+	if l.tokens != nil {
+		w["tokens"] = l.tokens.Take(float64(estimatedTokens))
+	}
+	return w
+}
+
+// release frees the concurrency slot of a call and corrects the token reservation made by
+// acquire with the usage reported by the API, when known
+func (l *limiter) release(estimatedTokens int, usage *tokenUsage) {
+	if l.concurrent != nil {
+		l.concurrent.Release()
+	}
+	if l.tokens != nil && usage != nil {
+		l.tokens.Adjust(float64(usage.TotalTokens - estimatedTokens))
+	}
+}
+
+// estimateTokens approximates the prompt tokens of a payload at four characters a token
+func estimateTokens(requestPayload map[string]interface{}) int {
+	messages, _ := requestPayload["messages"].([]map[string]string)
+	chars := 0
+	for _, m := range messages {
+		chars += utf8.RuneCountInString(m["content"])
+	}
+	return chars/4 + 1
+}
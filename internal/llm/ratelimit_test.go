@@ -0,0 +1,66 @@
+package llm_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/metrics"
+)
+
+func TestClientMaxConcurrent(t *testing.T) {
+	var inFlight llmtest.InFlight
+	client := llmtest.NewClient(t, inFlight.Handler(20*time.Millisecond), llm.WithRateLimit("openai", "", llm.RateLimit{MaxConcurrent: 2}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.CallLLM("hi"); err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if inFlight.Max() != 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", inFlight.Max())
+	}
+}
+
+func TestRateLimitWaitMetrics(t *testing.T) {
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		content := `{\"original_prompt\": \"test event\", \"predictions\": [{\"timeframe\": \"1 week\", \"description\": \"Event A\", \"impact\": \"Market volatility\"}]}`
+		if llmtest.IsCritique(body) {
+			content = `{\"original_prompt\": \"test event\", \"predictions\": [{\"timeframe\": \"1 week\", \"description\": \"Event A\", \"impact\": \"Market volatility\", \"confidence\": 0.5, \"critique\": \"Plausible\"}]}`
+		}
+		// The first call alone reports more tokens than the per-minute budget
+		return http.StatusOK, `{"choices": [{"message": {"content": "` + content + `"}}], "usage": {"prompt_tokens": 60000, "completion_tokens": 10, "total_tokens": 60010}}`
+	}, llm.WithRateLimit("openai", "o1-mini", llm.RateLimit{TokensPerMinute: 60000}))
+
+	registry := metrics.NewRegistry()
+	started := time.Now()
+	if _, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithMetrics(llm.NewMetrics(registry))); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 10*time.Millisecond {
+		t.Errorf("Expected the critique call to wait for the token budget, run took %v", elapsed)
+	}
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	expected := `nostradamus_rate_limit_wait_seconds_count{provider="openai",model="o1-mini",limit="tokens"} 2`
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("Expected metrics to contain %q, got:\n%s", expected, buf.String())
+	}
+	if strings.Contains(buf.String(), `limit="requests"`) {
+		t.Errorf("Expected no wait recorded for unconfigured limits, got:\n%s", buf.String())
+	}
+}
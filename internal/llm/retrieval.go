@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// Retriever finds the passages of a document corpus most relevant to a query
+type Retriever interface {
+	Search(query string, k int) []models.Passage
+}
+
+// WithRetriever pulls the k passages of r most relevant to the input into the predictor
+// and critic prompts, and lets predictions cite them
+func WithRetriever(r Retriever, k int) Option {
+	return func(o *options) {
+		o.retriever = r
+		o.topK = k
+	}
+}
+
+// retrieve looks up the passages relevant to the input, if a retriever is set
+func (p *pipeline) retrieve() {
+	if p.opts.retriever == nil || p.opts.topK <= 0 {
+		return
+	}
+	stage := p.root.child("retrieval", "stage", "retrieval")
+	defer stage.span.End()
+	p.passages = p.opts.retriever.Search(p.input, p.opts.topK)
+	p.sources = models.NewPassageSet(p.passages)
+	stage.span.SetAttribute("passages", len(p.passages))
+	stage.log.Info("Retrieved passages", "passages", len(p.passages))
+}
+
+// passageInstructions lists the retrieved passages for the predictor and asks it to cite
+// the ones each prediction relies on; it is empty when there are none
+func passageInstructions(passages []models.Passage) string {
+	if len(passages) == 0 {
+		return ""
+	}
+	return "Use the following research passages where they are relevant. Each item must also contain \"sources\", an array of the IDs of the passages it relies on, empty when it relies on none; cite only IDs listed here. " + formatPassages(passages)
+}
+
+// passageReviewInstructions lists the retrieved passages for the critic; it is empty when
+// there are none
+func passageReviewInstructions(passages []models.Passage) string {
+	if len(passages) == 0 {
+		return ""
+	}
+	return "The predictions cite in \"sources\" the IDs of the research passages below they rely on. Weigh whether the passages support each prediction when rating its confidence, and keep \"sources\" unchanged. " + formatPassages(passages)
+}
+
+// formatPassages writes passages as a list of IDs and texts
+func formatPassages(passages []models.Passage) string {
+	var b strings.Builder
+	b.WriteString("Research passages:")
+	for _, passage := range passages {
+		fmt.Fprintf(&b, "\n[%s] %s", passage.ID, strings.Join(strings.Fields(passage.Text), " "))
+	}
+	return b.String()
+}
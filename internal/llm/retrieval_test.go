@@ -0,0 +1,49 @@
+package llm_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/retrieval"
+)
+
+func TestRetrievedPassagesInPrompts(t *testing.T) {
+	idx, err := retrieval.Build("../retrieval/testdata/notes", 200)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+	var predictorPrompt string
+	var critiquePrompts []string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			predictorPrompt = body
+			return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses", "sources": ["banks.md#1"]}]}`
+		}
+		critiquePrompts = append(critiquePrompts, body)
+		source := "notes.md#9"
+		if len(critiquePrompts) > 1 {
+			source = "banks.md#1"
+		}
+		return http.StatusOK, `{"original_prompt": "x", "predictions": [{"timeframe": "1 month", "description": "Banks fall", "impact": "Losses", "sources": ["` + source + `"], "confidence": 0.7, "critique": "Supported by the notes"}]}`
+	})
+
+	result, err := llm.GenerateCritiquedResponse("Deposit flight from regional banks", client, llm.WithRetriever(idx, 1))
+	if err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+	if !strings.Contains(predictorPrompt, `[banks.md#1] # Regional banks Regional banks hold`) || !strings.Contains(predictorPrompt, `\"sources\"`) {
+		t.Errorf("Expected the retrieved passage and citation instructions in the predictor prompt, got: %s", predictorPrompt)
+	}
+	if strings.Contains(predictorPrompt, "OPEC+") {
+		t.Errorf("Expected only the top passage in the prompt, got: %s", predictorPrompt)
+	}
+	if len(critiquePrompts) != 2 || !strings.Contains(critiquePrompts[0], "[banks.md#1]") {
+		t.Fatalf("Expected the passage in the critic prompt and a retry for the unknown source, got %d critiques", len(critiquePrompts))
+	}
+	if len(result.Passages) != 1 || result.Passages[0].ID != "banks.md#1" || result.Predictions[0].Sources[0] != "banks.md#1" {
+		t.Errorf("Expected the retrieved passage and its citation in the output, got %+v", result)
+	}
+}
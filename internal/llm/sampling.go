@@ -0,0 +1,148 @@
+package llm
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/similarity"
+)
+
+// sampledPrediction is a cluster of similar predictions found across sampled prediction sets
+type sampledPrediction struct {
+	Prediction models.Prediction
+	// Frequency is the share of samples in which the prediction appeared
+	Frequency float64
+
+	// cluster is the index of the cluster and parents those of the clusters holding the
+	// parents of the representative prediction in its sample
+	cluster int
+	parents []int
+}
+
+// samplePredictions draws opts.samples prediction sets at opts.sampleTemperature, clusters
+// similar predictions across them and returns one representative per cluster, most
+// frequent first.
+func (p *pipeline) samplePredictions() ([]sampledPrediction, error) {
+	o := p.opts
+	call := func(sc scope, prompt string) (string, error) {
+		return p.client.callLLMWithTemperature(sc, prompt, o.sampleTemperature)
+	}
+
+	var predictions []models.Prediction
+	var sampleOf []int
+	for sample := 0; sample < o.samples; sample++ {
+		sampleScope := p.root.child("sample", "sample", sample)
+		response, err := callWithRetry(sampleScope, call, "prediction", p.predictionPrompt(), p.validatePredictions)
+		sampleScope.span.SetError(err)
+		sampleScope.span.End()
+		if err != nil {
+			return nil, err
+		}
+		for _, prediction := range response.Predictions {
+			predictions = append(predictions, prediction)
+			sampleOf = append(sampleOf, sample)
+		}
+	}
+
+	texts := make([]string, len(predictions))
+	for i, prediction := range predictions {
+		texts[i] = prediction.Description
+	}
+	clusters := similarity.Cluster(texts, similarity.Lexical, o.clusterThreshold)
+
+	// Prediction IDs are only unique within a sample
+	clusterOf := make(map[string]int)
+	for c, members := range clusters {
+		for _, m := range members {
+			if predictions[m].ID != "" {
+				clusterOf[fmt.Sprintf("%d/%s", sampleOf[m], predictions[m].ID)] = c
+			}
+		}
+	}
+
+	sampled := make([]sampledPrediction, 0, len(clusters))
+	for c, members := range clusters {
+		seen := make(map[int]bool)
+		for _, m := range members {
+			seen[sampleOf[m]] = true
+		}
+		representative := predictions[members[0]]
+		var parents []int
+		for _, parent := range representative.Parents {
+			if pc, ok := clusterOf[fmt.Sprintf("%d/%s", sampleOf[members[0]], parent)]; ok {
+				parents = append(parents, pc)
+			}
+		}
+		sampled = append(sampled, sampledPrediction{
+			Prediction: representative,
+			Frequency:  float64(len(seen)) / float64(o.samples),
+			cluster:    c,
+			parents:    parents,
+		})
+	}
+	sort.SliceStable(sampled, func(i, j int) bool {
+		return sampled[i].Frequency > sampled[j].Frequency
+	})
+	if len(sampled) > models.MaxPredictions {
+		sampled = sampled[:models.MaxPredictions]
+	}
+	linkSampled(sampled)
+	return sampled, nil
+}
+
+// linkSampled gives the representatives IDs unique across samples, when the samples named
+// their predictions, and points their parents at the representatives of the parents'
+// clusters. Parents whose cluster was dropped are removed, and if merging clusters closed
+// a cycle the graph is dropped.
+func linkSampled(sampled []sampledPrediction) {
+	ids := make(map[int]string, len(sampled))
+	identified := false
+	for i, s := range sampled {
+		ids[s.cluster] = fmt.Sprintf("p%d", i+1)
+		identified = identified || s.Prediction.ID != "" || len(s.parents) > 0
+	}
+	if !identified {
+		return
+	}
+	for i := range sampled {
+		s := &sampled[i]
+		s.Prediction.ID = ids[s.cluster]
+		s.Prediction.Parents = nil
+		for _, pc := range s.parents {
+			if id, ok := ids[pc]; ok && pc != s.cluster && !slices.Contains(s.Prediction.Parents, id) {
+				s.Prediction.Parents = append(s.Prediction.Parents, id)
+			}
+		}
+	}
+	if err := (models.PredictionResponse{Predictions: representatives(sampled)}).ValidateGraph(); err != nil {
+		for i := range sampled {
+			sampled[i].Prediction.Parents = nil
+		}
+	}
+}
+
+// representatives returns the representative prediction of each sampled cluster
+func representatives(sampled []sampledPrediction) []models.Prediction {
+	predictions := make([]models.Prediction, len(sampled))
+	for i, s := range sampled {
+		predictions[i] = s.Prediction
+	}
+	return predictions
+}
+
+// applyFrequencies sets the frequency of each critiqued prediction from the most
+// similar sampled cluster, leaving it unset when no cluster reaches threshold
+func applyFrequencies(predictions []models.CritiquedPrediction, sampled []sampledPrediction, threshold float64) {
+	for i := range predictions {
+		best := 0.0
+		for _, s := range sampled {
+			score := similarity.Lexical(s.Prediction.Description, predictions[i].Description)
+			if score >= threshold && score > best {
+				best = score
+				predictions[i].Frequency = s.Frequency
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+)
+
+func TestSampledPredictionsCarryFrequency(t *testing.T) {
+	samples := []string{
+		`{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Oil prices spike on supply fears", "impact": "Energy stocks rally"}, {"timeframe": "1 year", "description": "Airlines cut routes", "impact": "Airline stocks fall"}]}`,
+		`{"original_prompt": "test event", "predictions": [{"timeframe": "2 weeks", "description": "Oil prices spike on supply fears in Europe", "impact": "Energy stocks rally"}]}`,
+		`{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Oil prices spike on supply fears", "impact": "Energy stocks rally"}]}`,
+	}
+	sampleCount := 0
+	var critiqueBody string
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		switch {
+		case strings.Contains(body, "predictor of future stock market events"):
+			if !strings.Contains(body, `"temperature":1.2`) {
+				t.Errorf("Expected sampling temperature in request, got: %s", body)
+			}
+			sampleCount++
+			return http.StatusOK, samples[sampleCount-1]
+		case llmtest.IsCritique(body):
+			critiqueBody = body
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Oil prices spike on supply fears", "impact": "Energy stocks rally", "confidence": 0.8, "critique": "Likely"}, {"timeframe": "1 year", "description": "Airlines cut routes", "impact": "Airline stocks fall", "confidence": 0.4, "critique": "Possible"}]}`
+		}
+		t.Errorf("Unexpected request: %s", body)
+		return http.StatusBadRequest, "unexpected request"
+	})
+
+	result, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithSamples(3), llm.WithSampleTemperature(1.2))
+	if err != nil {
+		t.Fatalf("Expected valid sampled response, got error: %v", err)
+	}
+	if sampleCount != 3 {
+		t.Errorf("Expected 3 prediction samples, got: %d", sampleCount)
+	}
+	if strings.Contains(critiqueBody, "in Europe") {
+		t.Error("Expected similar predictions to be merged before the critique")
+	}
+	var cr models.CritiquedResponse
+	if err := json.Unmarshal([]byte(result), &cr); err != nil {
+		t.Fatalf("Failed to parse sampled response: %v", err)
+	}
+	if cr.Predictions[0].Frequency != 1 {
+		t.Errorf("Expected frequency 1 for the prediction found in every sample, got: %f", cr.Predictions[0].Frequency)
+	}
+	if got := cr.Predictions[1].Frequency; got < 0.33 || got > 0.34 {
+		t.Errorf("Expected frequency 1/3 for the prediction found in one sample, got: %f", got)
+	}
+	if cr.Trace == nil || cr.Trace.Samples != 3 {
+		t.Errorf("Expected trace to record 3 samples, got: %+v", cr.Trace)
+	}
+}
@@ -0,0 +1,102 @@
+package llm_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/llm"
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+	"nostradamus/internal/tracing"
+)
+
+func TestRunTracing(t *testing.T) {
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if !llmtest.IsCritique(body) {
+			return http.StatusOK, `{"choices": [{"message": {"content": "{\"original_prompt\": \"test event\", \"predictions\": [{\"timeframe\": \"1 week\", \"description\": \"Event A\", \"impact\": \"Market volatility\"}]}"}}], "usage": {"prompt_tokens": 120, "completion_tokens": 80, "total_tokens": 200}}`
+		}
+		critiqueCount++
+		if critiqueCount == 1 {
+			return http.StatusOK, `{"original_prompt": "test event", "predictions": []}`
+		}
+		return http.StatusOK, llmtest.Critique
+	})
+
+	exporter := &tracing.MemoryExporter{}
+	if _, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithTracer(tracing.NewTracer(exporter))); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+
+	spans := exporter.Spans()
+	byName := make(map[string][]tracing.SpanData)
+	for _, s := range spans {
+		byName[s.Name] = append(byName[s.Name], s)
+		if s.TraceID != spans[0].TraceID {
+			t.Errorf("Expected every span in the same trace, got: %+v", s)
+		}
+	}
+	if len(byName["run"]) != 1 || byName["run"][0].ParentSpanID != "" || byName["run"][0].Status != tracing.StatusOK {
+		t.Fatalf("Expected one successful root run span, got: %+v", byName["run"])
+	}
+	if len(byName["critique"]) != 1 || byName["critique"][0].Attributes["attempts"] != 2 {
+		t.Errorf("Expected a critique stage span with 2 attempts, got: %+v", byName["critique"])
+	}
+	if len(byName["attempt"]) != 3 || len(byName["POST"]) != 3 {
+		t.Errorf("Expected 3 attempt and 3 HTTP spans, got: %d and %d", len(byName["attempt"]), len(byName["POST"]))
+	}
+	failed := 0
+	for _, s := range byName["attempt"] {
+		if s.Status == tracing.StatusError && s.Attributes["validation.rule"] == models.RulePredictionCount {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected one attempt span with the broken validation rule, got: %d", failed)
+	}
+	var usage []tracing.SpanData
+	for _, s := range byName["POST"] {
+		if s.Kind != tracing.KindClient || s.Attributes["llm.model"] != "o1-mini" || s.Attributes["http.response.status_code"] != 200 {
+			t.Errorf("Expected HTTP client span with model and status, got: %+v", s)
+		}
+		if s.Attributes["llm.usage.total_tokens"] != nil {
+			usage = append(usage, s)
+		}
+	}
+	if len(usage) != 1 || usage[0].Attributes["llm.usage.total_tokens"] != 200 {
+		t.Errorf("Expected token usage on the prediction HTTP span, got: %+v", usage)
+	}
+}
+
+func TestTraceOmitsErrorText(t *testing.T) {
+	critiqueCount := 0
+	client := llmtest.NewClient(t, func(body string) (int, string) {
+		if llmtest.IsCritique(body) {
+			critiqueCount++
+			if critiqueCount == 1 {
+				return http.StatusBadGateway, "upstream leaked sk-proxy-secret"
+			}
+		}
+		return llmtest.Answer(body)
+	})
+
+	exporter := &tracing.MemoryExporter{}
+	if _, err := llm.GenerateCritiquedPredictions("test event", client, llm.WithTracer(tracing.NewTracer(exporter))); err != nil {
+		t.Fatalf("Expected valid response, got error: %v", err)
+	}
+
+	failed := 0
+	for _, s := range exporter.Spans() {
+		if s.Status == tracing.StatusError {
+			failed++
+		}
+	}
+	encoded, err := tracing.EncodeOTLP(exporter.Spans())
+	if err != nil {
+		t.Fatalf("Failed to encode OTLP: %v", err)
+	}
+	if failed == 0 || strings.Contains(string(encoded), "sk-proxy-secret") {
+		t.Errorf("Expected failed spans without the API error body, got: %s", encoded)
+	}
+}
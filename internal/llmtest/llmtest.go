@@ -0,0 +1,122 @@
+// Package llmtest fakes the OpenAI-compatible chat completions API for the tests of the
+// packages calling it
+package llmtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nostradamus/internal/config"
+	"nostradamus/internal/llm"
+)
+
+const (
+	// Predictions is a valid predictor response for the input "test event"
+	Predictions = `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility"}]}`
+	// Critique is a valid critic response to Predictions
+	Critique = `{"original_prompt": "test event", "predictions": [{"timeframe": "1 week", "description": "Event A", "impact": "Market volatility", "confidence": 0.5, "critique": "Plausible"}]}`
+)
+
+// Handler answers the JSON body of a chat completions request with a status code and a
+// response body
+type Handler func(body string) (int, string)
+
+// Answer is a Handler replying Critique to critique prompts and Predictions to any other
+func Answer(body string) (int, string) {
+	if IsCritique(body) {
+		return http.StatusOK, Critique
+	}
+	return http.StatusOK, Predictions
+}
+
+// IsCritique reports whether body asks the critic to review predictions
+func IsCritique(body string) bool {
+	return strings.Contains(body, "Critically review")
+}
+
+// InFlight counts the requests its Handler is serving at once
+type InFlight struct {
+	current, max atomic.Int32
+}
+
+// Handler returns a Handler holding each request for delay before answering "ok"
+func (f *InFlight) Handler(delay time.Duration) Handler {
+	return func(string) (int, string) {
+		n := f.current.Add(1)
+		for {
+			max := f.max.Load()
+			if n <= max || f.max.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		f.current.Add(-1)
+		return http.StatusOK, `{"choices": [{"message": {"content": "ok"}}]}`
+	}
+}
+
+// Max returns the most requests served at once since f was created or last reset
+func (f *InFlight) Max() int {
+	return int(f.max.Load())
+}
+
+// Reset forgets the maximum seen so far
+func (f *InFlight) Reset() {
+	f.max.Store(0)
+}
+
+// RoundTripFunc is an http.RoundTripper calling a function, for fakes that need the request
+// itself, such as its URL or headers
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f with req
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Response returns an HTTP response with status and body
+func Response(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     make(http.Header),
+	}
+}
+
+// HTTPClient returns an HTTP client whose requests are answered by handler
+func HTTPClient(handler Handler) *http.Client {
+	return &http.Client{
+		Transport: RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			return Response(handler(string(body))), nil
+		}),
+	}
+}
+
+// Setup sets an OpenAI API key and shortens config.RetryDelay until t ends
+func Setup(t testing.TB) {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "testkey")
+	delay := config.RetryDelay
+	config.RetryDelay = time.Millisecond
+	t.Cleanup(func() { config.RetryDelay = delay })
+}
+
+// NewClient calls Setup and returns an LLM client whose requests are answered by handler
+func NewClient(t testing.TB, handler Handler, opts ...llm.ClientOption) *llm.Client {
+	t.Helper()
+	Setup(t)
+	client, err := llm.NewClient(HTTPClient(handler), opts...)
+	if err != nil {
+		t.Fatalf("Failed to create LLM client: %v", err)
+	}
+	return client
+}
@@ -1,15 +1,19 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strings"
+
+	"nostradamus/internal/config"
 )
 
 var DefaultLogger *Logger
 
 func init() {
-	DefaultLogger = New(os.Getenv("DEBUG") == "1")
+	DefaultLogger = NewFromConfig(config.New())
 }
 
 // Info logs an informational message
@@ -22,21 +26,94 @@ func Error(msg string, keysAndValues ...interface{}) {
 	DefaultLogger.Error(msg, keysAndValues...)
 }
 
+// AddSecrets masks more secret values in the records of l
+func (l *Logger) AddSecrets(secrets ...string) {
+	if h, ok := l.Handler().(*RedactingHandler); ok {
+		h.AddSecrets(secrets...)
+	}
+}
+
 // Logger wraps slog.Logger to provide structured logging
 type Logger struct {
 	*slog.Logger
 }
 
+// Options configures a Logger
+type Options struct {
+	Level slog.Level
+	// Format is "text" or "json"
+	Format string
+	// Output receives the log records; nil discards them
+	Output io.Writer
+	// Content selects how prompt and response bodies are logged; empty means full
+	Content ContentMode
+	// Secrets are values masked wherever they appear in records
+	Secrets []string
+}
+
 // New creates a new Logger instance
 func New(debug bool) *Logger {
-	var handler slog.Handler
 	if debug {
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+		return NewWithOptions(Options{Level: slog.LevelDebug, Output: os.Stderr})
+	}
+	return NewWithOptions(Options{Level: slog.LevelDebug})
+}
+
+// NewWithOptions creates a Logger writing records at or above opts.Level to opts.Output.
+// Records always go through a RedactingHandler.
+func NewWithOptions(opts Options) *Logger {
+	output := opts.Output
+	if output == nil {
+		output = io.Discard
+	}
+	handlerOptions := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	if strings.EqualFold(opts.Format, "json") {
+		handler = slog.NewJSONHandler(output, handlerOptions)
 	} else {
-		handler = slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug})
+		handler = slog.NewTextHandler(output, handlerOptions)
 	}
-	
+
 	return &Logger{
-		Logger: slog.New(handler),
+		Logger: slog.New(NewRedactingHandler(handler, opts.Content, opts.Secrets...)),
+	}
+}
+
+// NewFromConfig creates the Logger described by cfg, writing to stderr so stdout only
+// carries results. LogLevel takes precedence over Debug; an unknown level falls back to Debug,
+// and an unknown content mode falls back to hashed so nothing sensitive leaks by mistake.
+func NewFromConfig(cfg *config.Config) *Logger {
+	level, enabled, err := ParseLevel(cfg.LogLevel)
+	if cfg.LogLevel == "" || err != nil {
+		level, enabled = slog.LevelDebug, cfg.Debug
+	}
+	content, err := ParseContentMode(cfg.LogContent)
+	if err != nil {
+		content = ContentHashed
+	}
+	opts := Options{Level: level, Format: cfg.LogFormat, Content: content, Secrets: cfg.Secrets}
+	if enabled {
+		opts.Output = os.Stderr
+	}
+	return NewWithOptions(opts)
+}
+
+// ParseLevel parses "debug", "info", "warn", "error" or "off". The returned bool is
+// false when logging is turned off.
+func ParseLevel(s string) (slog.Level, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, true, nil
+	case "info":
+		return slog.LevelInfo, true, nil
+	case "warn", "warning":
+		return slog.LevelWarn, true, nil
+	case "error":
+		return slog.LevelError, true, nil
+	case "off", "none":
+		return slog.LevelError, false, nil
+	default:
+		return slog.LevelInfo, false, fmt.Errorf("unknown log level %q", s)
 	}
 }
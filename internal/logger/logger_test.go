@@ -0,0 +1,21 @@
+package logger_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"nostradamus/internal/logger"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	level, enabled, err := logger.ParseLevel("WARN")
+	if err != nil || !enabled || level != slog.LevelWarn {
+		t.Errorf("Expected enabled warn level, got: %v %v %v", level, enabled, err)
+	}
+	if _, enabled, err := logger.ParseLevel("off"); err != nil || enabled {
+		t.Errorf("Expected logging off, got: %v %v", enabled, err)
+	}
+	if _, _, err := logger.ParseLevel("verbose"); err == nil {
+		t.Error("Expected error for unknown level, got nil")
+	}
+}
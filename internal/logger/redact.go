@@ -0,0 +1,234 @@
+package logger
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ContentMode selects how prompt and response bodies appear in logs
+type ContentMode string
+
+const (
+	// ContentFull logs bodies as they are
+	ContentFull ContentMode = "full"
+	// ContentTruncated logs the start of bodies and their length
+	ContentTruncated ContentMode = "truncated"
+	// ContentHashed logs a SHA-256 digest of bodies, so equal bodies can still be matched
+	ContentHashed ContentMode = "hashed"
+)
+
+// redacted replaces the value of secrets in logs
+const redacted = "[REDACTED]"
+
+// truncatedLength is the number of characters kept by ContentTruncated
+const truncatedLength = 64
+
+// sensitiveKeys are attribute keys whose values are always masked
+var sensitiveKeys = map[string]bool{
+	"api_key": true, "apikey": true, "authorization": true, "token": true,
+	"password": true, "secret": true, "cookie": true,
+}
+
+// contentKeys are attribute keys holding prompt or response bodies
+var contentKeys = map[string]bool{
+	"input": true, "prompt": true, "response": true, "result": true, "body": true, "content": true,
+}
+
+// secretPatterns match API keys and bearer tokens inside free text
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`),
+	regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/\-]+=*`),
+}
+
+// ParseContentMode parses "full", "truncated" or "hashed"; empty means full
+func ParseContentMode(s string) (ContentMode, error) {
+	switch mode := ContentMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ContentFull, nil
+	case ContentFull, ContentTruncated, ContentHashed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown content mode %q", s)
+	}
+}
+
+// RedactingHandler masks API keys, Authorization headers and known secrets in every
+// record before passing it on, and rewrites prompt and response bodies according to
+// its ContentMode.
+type RedactingHandler struct {
+	next    slog.Handler
+	content ContentMode
+	// secrets are shared with the handlers derived by WithAttrs and WithGroup, so secrets
+	// added later are masked by all of them
+	secrets *secretList
+}
+
+// secretList is a set of secret values that can grow while records are logged
+type secretList struct {
+	mu     sync.RWMutex
+	values []string
+}
+
+// add appends the non-empty values
+func (l *secretList) add(values ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			l.values = append(l.values, v)
+		}
+	}
+}
+
+// NewRedactingHandler wraps next, masking the given secret values wherever they appear
+func NewRedactingHandler(next slog.Handler, content ContentMode, secrets ...string) *RedactingHandler {
+	h := &RedactingHandler{next: next, content: content, secrets: &secretList{}}
+	h.secrets.add(secrets...)
+	return h
+}
+
+// AddSecrets masks more secret values, such as the ones read from a config profile after
+// the handler was created
+func (h *RedactingHandler) AddSecrets(secrets ...string) {
+	h.secrets.add(secrets...)
+}
+
+// Enabled reports whether the wrapped handler handles records at level
+func (h *RedactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle redacts the record and passes it to the wrapped handler
+func (h *RedactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	record := slog.NewRecord(r.Time, r.Level, h.scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs redacts attrs before attaching them to the wrapped handler
+func (h *RedactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redact(a)
+	}
+	return &RedactingHandler{next: h.next.WithAttrs(redactedAttrs), content: h.content, secrets: h.secrets}
+}
+
+// WithGroup opens a group on the wrapped handler
+func (h *RedactingHandler) WithGroup(name string) slog.Handler {
+	return &RedactingHandler{next: h.next.WithGroup(name), content: h.content, secrets: h.secrets}
+}
+
+// redact returns a with secrets masked and bodies rewritten
+func (h *RedactingHandler) redact(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+	key := strings.ToLower(a.Key)
+	switch {
+	case sensitiveKeys[key]:
+		return slog.String(a.Key, redacted)
+	case value.Kind() == slog.KindGroup:
+		group := value.Group()
+		attrs := make([]any, len(group))
+		for i, member := range group {
+			attrs[i] = h.redact(member)
+		}
+		return slog.Group(a.Key, attrs...)
+	case contentKeys[key]:
+		return slog.String(a.Key, h.rewriteContent(value))
+	}
+
+	if header, ok := value.Any().(http.Header); ok && value.Kind() == slog.KindAny {
+		masked := header.Clone()
+		for name := range masked {
+			if sensitiveKeys[strings.ToLower(name)] || strings.EqualFold(name, "Set-Cookie") || strings.EqualFold(name, "X-Api-Key") {
+				masked[name] = []string{redacted}
+			}
+		}
+		return slog.Any(a.Key, masked)
+	}
+	if err, ok := value.Any().(error); ok && value.Kind() == slog.KindAny {
+		message := err.Error()
+		for _, body := range responseBodies(err) {
+			message = strings.ReplaceAll(message, body, h.rewriteContent(slog.StringValue(body)))
+		}
+		return slog.String(a.Key, h.scrub(message))
+	}
+	if value.Kind() == slog.KindString {
+		return slog.String(a.Key, h.scrub(value.String()))
+	}
+	return slog.Attr{Key: a.Key, Value: value}
+}
+
+// responseBodies returns the non-empty response bodies carried by err and the errors it
+// wraps, such as the failures of every backend tried by a failover client
+func responseBodies(err error) []string {
+	if err == nil {
+		return nil
+	}
+	if bodyErr, ok := err.(interface{ ResponseBody() string }); ok && bodyErr.ResponseBody() != "" {
+		return []string{bodyErr.ResponseBody()}
+	}
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() error }:
+		return responseBodies(wrapped.Unwrap())
+	case interface{ Unwrap() []error }:
+		var bodies []string
+		for _, e := range wrapped.Unwrap() {
+			bodies = append(bodies, responseBodies(e)...)
+		}
+		return bodies
+	}
+	return nil
+}
+
+// rewriteContent renders a body according to the handler's ContentMode
+func (h *RedactingHandler) rewriteContent(value slog.Value) string {
+	text := value.String()
+	if value.Kind() == slog.KindAny {
+		if encoded, err := json.Marshal(value.Any()); err == nil {
+			text = string(encoded)
+		}
+	}
+	text = h.scrub(text)
+
+	switch h.content {
+	case ContentTruncated:
+		runes := []rune(text)
+		if len(runes) <= truncatedLength {
+			return text
+		}
+		return fmt.Sprintf("%s... (%d chars)", string(runes[:truncatedLength]), len(runes))
+	case ContentHashed:
+		return fmt.Sprintf("sha256:%x (%d chars)", sha256.Sum256([]byte(text)), len([]rune(text)))
+	default:
+		return text
+	}
+}
+
+// scrub masks API keys, bearer tokens and the handler's secrets inside s
+func (h *RedactingHandler) scrub(s string) string {
+	h.secrets.mu.RLock()
+	for _, secret := range h.secrets.values {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	h.secrets.mu.RUnlock()
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if strings.HasPrefix(match, "sk-") {
+				return "sk-" + redacted
+			}
+			return "Bearer " + redacted
+		})
+	}
+	return s
+}
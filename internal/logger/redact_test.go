@@ -0,0 +1,78 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/logger"
+)
+
+// apiError is an error carrying an API response body, like the LLM client's
+type apiError struct {
+	body string
+}
+
+func (e *apiError) Error() string        { return "API returned status 401: " + e.body }
+func (e *apiError) ResponseBody() string { return e.body }
+
+func TestRedactingHandler(t *testing.T) {
+	var logs bytes.Buffer
+	log := logger.NewWithOptions(logger.Options{
+		Level:   slog.LevelDebug,
+		Format:  "json",
+		Output:  &logs,
+		Content: logger.ContentTruncated,
+		Secrets: []string{"my-secret-key"},
+	})
+	apiErr := fmt.Errorf("critique: %w", &apiError{body: strings.Repeat("x", 100) + " key my-secret-key"})
+	header := http.Header{"Authorization": {"Bearer abc.def"}, "Content-Type": {"application/json"}}
+	log.Info("Calling with sk-abcdefghijklmnop",
+		"authorization", "Bearer abc.def",
+		"headers", header,
+		"prompt", strings.Repeat("p", 100),
+		"error", apiErr,
+	)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("Failed to parse log record: %v", err)
+	}
+	if record["msg"] != "Calling with sk-[REDACTED]" {
+		t.Errorf("Expected API key masked in message, got: %v", record["msg"])
+	}
+	if record["authorization"] != "[REDACTED]" {
+		t.Errorf("Expected authorization masked, got: %v", record["authorization"])
+	}
+	headers, _ := record["headers"].(map[string]interface{})
+	if auth, _ := headers["Authorization"].([]interface{}); len(auth) != 1 || auth[0] != "[REDACTED]" {
+		t.Errorf("Expected Authorization header masked, got: %v", record["headers"])
+	}
+	if prompt, _ := record["prompt"].(string); !strings.HasSuffix(prompt, "... (100 chars)") || len(prompt) > 100 {
+		t.Errorf("Expected prompt truncated, got: %v", record["prompt"])
+	}
+	errText, _ := record["error"].(string)
+	if strings.Contains(errText, "my-secret-key") || strings.Contains(errText, strings.Repeat("x", 100)) || !strings.HasPrefix(errText, "critique: API returned status 401: ") {
+		t.Errorf("Expected API error body truncated and secret masked, got: %v", errText)
+	}
+
+	// Secrets added later are masked too, including by loggers derived with With
+	logs.Reset()
+	derived := log.With("stage", "critique")
+	log.AddSecrets("tok-3f9a1c")
+	derived.Info("Calling", "header", "X-Token: tok-3f9a1c")
+	if strings.Contains(logs.String(), "tok-3f9a1c") {
+		t.Errorf("Expected the added secret masked, got: %s", logs.String())
+	}
+
+	logs.Reset()
+	hashed := logger.NewWithOptions(logger.Options{Level: slog.LevelDebug, Format: "json", Output: &logs, Content: logger.ContentHashed})
+	hashed.Info("Received input", "input", "China has taken over Taiwan")
+	if strings.Contains(logs.String(), "Taiwan") || !strings.Contains(logs.String(), "sha256:") {
+		t.Errorf("Expected input hashed, got: %s", logs.String())
+	}
+}
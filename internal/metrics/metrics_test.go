@@ -0,0 +1,58 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/metrics"
+)
+
+func TestWriteText(t *testing.T) {
+	registry := metrics.NewRegistry()
+	calls := registry.NewCounter("calls_total", "Calls made.\nPer model", "model")
+	calls.Inc("o1-mini")
+	calls.Add(2, `say "hi"`)
+	calls.Inc("o1-mini")
+	latency := registry.NewHistogram("latency_seconds", "Call latency", []float64{1, 0.5}, "stage")
+	latency.Observe(0.2, "critique")
+	latency.Observe(0.7, "critique")
+	latency.Observe(3, "critique")
+
+	if calls.Value("o1-mini") != 2 || latency.Count("critique") != 3 || latency.Count("predict") != 0 {
+		t.Errorf("Expected 2 calls and 3 observations, got: %v and %d", calls.Value("o1-mini"), latency.Count("critique"))
+	}
+
+	var nilCounter *metrics.Counter
+	var nilHistogram *metrics.Histogram
+	nilCounter.Inc("o1-mini")
+	nilHistogram.Observe(1, "critique")
+
+	var buf bytes.Buffer
+	if err := registry.WriteText(&buf); err != nil {
+		t.Fatalf("Failed to write metrics: %v", err)
+	}
+	expected := `# HELP calls_total Calls made.\nPer model
+# TYPE calls_total counter
+calls_total{model="o1-mini"} 2
+calls_total{model="say \"hi\""} 2
+# HELP latency_seconds Call latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{stage="critique",le="0.5"} 1
+latency_seconds_bucket{stage="critique",le="1"} 2
+latency_seconds_bucket{stage="critique",le="+Inf"} 3
+latency_seconds_sum{stage="critique"} 3.9
+latency_seconds_count{stage="critique"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Expected exposition:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") || recorder.Body.String() != expected {
+		t.Errorf("Expected the handler to serve the text format, got: %s\n%s", recorder.Header().Get("Content-Type"), recorder.Body.String())
+	}
+}
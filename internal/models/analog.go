@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reaction is how a sector reacted to a past event
+type Reaction struct {
+	Sector    string `json:"sector"`
+	Direction string `json:"direction"`
+}
+
+// Validate checks the reaction is tagged with the bundled sectors and directions
+func (r Reaction) Validate() error {
+	if !containsFold(Sectors, r.Sector) || !containsFold(Directions, r.Direction) {
+		return fmt.Errorf("unknown outcome %s %s", r.Sector, r.Direction)
+	}
+	return nil
+}
+
+// Analog is a past market event with the sector reactions observed after it
+type Analog struct {
+	ID    string `json:"id"`
+	Date  string `json:"date"`
+	Event string `json:"event"`
+	// Horizon is the window the reactions were observed over, e.g. "1 month"
+	Horizon  string     `json:"horizon"`
+	Summary  string     `json:"summary"`
+	Outcomes []Reaction `json:"outcomes"`
+	// Similarity is how close the analog is to the event of a run, from 0 to 1
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// Validate checks the analog is complete, dated as YYYY-MM-DD and has outcomes tagged
+// with the bundled sectors and directions
+func (a Analog) Validate() error {
+	if a.ID == "" || a.Event == "" || len(a.Outcomes) == 0 {
+		return fmt.Errorf("analog %q needs an id, an event and outcomes", a.ID)
+	}
+	if _, err := time.Parse("2006-01-02", a.Date); err != nil {
+		return fmt.Errorf("analog %s: invalid date %q", a.ID, a.Date)
+	}
+	if _, err := ParseTimeframe(a.Horizon); err != nil {
+		return fmt.Errorf("analog %s: %w", a.ID, err)
+	}
+	for _, o := range a.Outcomes {
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("analog %s: %w", a.ID, err)
+		}
+	}
+	return nil
+}
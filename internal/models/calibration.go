@@ -0,0 +1,12 @@
+package models
+
+// Calibration describes the map the confidences of a response were calibrated with
+type Calibration struct {
+	Method string `json:"method"`
+	// Model and Persona are those of the resolved predictions the map was fitted on;
+	// empty when it was fitted on every model or persona
+	Model   string `json:"model,omitempty"`
+	Persona string `json:"persona,omitempty"`
+	// Records is the number of resolved predictions the map was fitted on
+	Records int `json:"records"`
+}
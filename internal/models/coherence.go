@@ -0,0 +1,100 @@
+package models
+
+import "strings"
+
+// Kinds of conflict between predictions
+const (
+	// ConflictExclusive groups alternative outcomes of which at most one can happen
+	ConflictExclusive = "mutually_exclusive"
+	// ConflictContradiction groups predictions asserting the opposite of one another
+	ConflictContradiction = "contradiction"
+)
+
+// Coherence modes deciding what happens to conflicting predictions
+const (
+	// CoherenceFlag reports conflicts and leaves confidences as critiqued
+	CoherenceFlag = "flag"
+	// CoherenceNormalise scales the confidences of each inconsistent group to sum to 1
+	CoherenceNormalise = "normalise"
+)
+
+// Conflict is a group of predictions that cannot all come true
+type Conflict struct {
+	Kind string `json:"kind"`
+	// Predictions are the indexes of the predictions in the group
+	Predictions []int  `json:"predictions"`
+	Explanation string `json:"explanation"`
+	// Total is the sum of the overall probabilities of the group's predictions before
+	// normalisation, calibrated if calibration is on
+	Total float64 `json:"total"`
+	// Inconsistent is set when Total exceeds 1, which no group of exclusive outcomes can
+	Inconsistent bool `json:"inconsistent,omitempty"`
+	// Normalised is set when the group's confidences were scaled to sum to 1
+	Normalised bool `json:"normalised,omitempty"`
+}
+
+// CoherenceCheck is the LLM's report of the conflicts among a run's predictions
+type CoherenceCheck struct {
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Validate checks that every conflict has a known kind, an explanation and at least two
+// distinct indexes among the n predictions checked
+func (c CoherenceCheck) Validate(n int) error {
+	for i, conflict := range c.Conflicts {
+		if conflict.Kind != ConflictExclusive && conflict.Kind != ConflictContradiction {
+			return invalid(RuleConflictKind, "conflict %d: unknown kind %q", i, conflict.Kind)
+		}
+		if strings.TrimSpace(conflict.Explanation) == "" {
+			return invalid(RuleMissingField, "conflict %d missing explanation", i)
+		}
+		seen := make(map[int]bool)
+		for _, p := range conflict.Predictions {
+			if p < 0 || p >= n {
+				return invalid(RuleConflictIndex, "conflict %d: prediction %d out of range", i, p)
+			}
+			seen[p] = true
+		}
+		if len(seen) < 2 {
+			return invalid(RuleConflictIndex, "conflict %d needs at least two distinct predictions", i)
+		}
+	}
+	return nil
+}
+
+// ApplyConflicts records conflicts on the response, deduplicating their indexes and
+// totalling the overall probabilities of their predictions, as given by Likelihood, so
+// probabilities must be propagated first when the predictions form a graph. In
+// CoherenceNormalise mode, the confidences of every group totalling more than 1 are scaled
+// so the overall probabilities sum to 1; a prediction in several groups is scaled by each
+// in turn. Probabilities of descendants must then be propagated again. The conflicts must
+// pass CoherenceCheck.Validate.
+func (r *CritiquedResponse) ApplyConflicts(conflicts []Conflict, mode string) {
+	r.Conflicts = nil
+	for _, conflict := range conflicts {
+		var indexes []int
+		seen := make(map[int]bool)
+		for _, p := range conflict.Predictions {
+			if !seen[p] {
+				seen[p] = true
+				indexes = append(indexes, p)
+			}
+		}
+		conflict.Predictions = indexes
+		conflict.Total = 0
+		for _, p := range indexes {
+			conflict.Total += r.Predictions[p].Likelihood()
+		}
+		conflict.Inconsistent = conflict.Total > 1
+		conflict.Normalised = false
+		if conflict.Inconsistent && mode == CoherenceNormalise {
+			// Scaling the confidence given the parents scales the overall probability alike
+			for _, p := range indexes {
+				r.Predictions[p].Confidence /= conflict.Total
+				r.Predictions[p].Probability /= conflict.Total
+			}
+			conflict.Normalised = true
+		}
+		r.Conflicts = append(r.Conflicts, conflict)
+	}
+}
@@ -1,16 +1,113 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 // CritiquedPrediction contains a prediction with critique info
 type CritiquedPrediction struct {
-	Timeframe   string  `json:"timeframe"`
-	Description string  `json:"description"`
-	Impact      string  `json:"impact"`
-	Confidence  float64 `json:"confidence"`
-	Critique    string  `json:"critique"`
+	ID          string   `json:"id,omitempty"`
+	Parents     []string `json:"parents,omitempty"`
+	Timeframe   string   `json:"timeframe"`
+	Description string   `json:"description"`
+	Impact      string   `json:"impact"`
+	Sectors     []string `json:"sectors,omitempty"`
+	Direction   string   `json:"direction,omitempty"`
+	Magnitude   string   `json:"magnitude,omitempty"`
+	Tickers     []string `json:"tickers,omitempty"`
+	Sources     []string `json:"sources,omitempty"`
+	// Confidence is, for a prediction with parents, the probability it happens given
+	// that its parents do
+	Confidence float64 `json:"confidence"`
+	// RawConfidence is the critic's confidence when Confidence was calibrated
+	RawConfidence *float64 `json:"raw_confidence,omitempty"`
+	Critique      string   `json:"critique"`
+	Frequency     float64  `json:"frequency,omitempty"`
+	// Probability is the unconditional probability of the prediction, propagated from
+	// the confidences of its ancestors; it is only set when predictions form a graph
+	Probability float64 `json:"probability,omitempty"`
 }
 
 // CritiquedResponse represents the critiqued predictions response
 type CritiquedResponse struct {
 	OriginalPrompt string                `json:"original_prompt"`
 	Predictions    []CritiquedPrediction `json:"predictions"`
+	Trace          *RunTrace             `json:"trace,omitempty"`
+	// Backend names the provider/model that produced the final critique
+	Backend string `json:"backend,omitempty"`
+	// Persona names the critic persona, to calibrate its confidences separately
+	Persona string `json:"persona,omitempty"`
+	// Calibration is set when the confidences were calibrated against past outcomes
+	Calibration *Calibration `json:"calibration,omitempty"`
+	// AsOf is the date the predictions were made on, as YYYY-MM-DD; timeframes count from it
+	AsOf string `json:"as_of,omitempty"`
+	// Passages are the research passages retrieved for the run, which predictions cite
+	// in their sources
+	Passages []Passage `json:"passages,omitempty"`
+	// Analogs are the past events, with their observed sector reactions, the critic
+	// anchored its confidences in
+	Analogs []Analog `json:"analogs,omitempty"`
+	// Conflicts are the groups of predictions found to be mutually exclusive or
+	// contradictory by the coherence check
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	// Merges are the near-duplicate predictions merged before the critique
+	Merges []Merge `json:"merges,omitempty"`
+}
+
+// AsOfDate returns the date the predictions were made on, or fallback when none is recorded
+func (r CritiquedResponse) AsOfDate(fallback time.Time) (time.Time, error) {
+	if r.AsOf == "" {
+		return fallback, nil
+	}
+	asOf, err := time.ParseInLocation("2006-01-02", r.AsOf, time.Local)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid as_of date %q: %w", r.AsOf, err)
+	}
+	return asOf, nil
+}
+
+// Validate checks that every prediction is complete, carries a confidence between 0 and 1
+// and has tags, when present, that belong to the bundled taxonomies, and that parents
+// form a causal graph without cycles or dangling references
+func (r CritiquedResponse) Validate() error {
+	if len(r.Predictions) == 0 || len(r.Predictions) > MaxPredictions {
+		return invalid(RulePredictionCount, "expected between 1 and %d predictions, got %d", MaxPredictions, len(r.Predictions))
+	}
+	for i, p := range r.Predictions {
+		if strings.TrimSpace(p.Timeframe) == "" || strings.TrimSpace(p.Description) == "" || strings.TrimSpace(p.Impact) == "" {
+			return invalid(RuleMissingField, "prediction %d missing one or more fields", i)
+		}
+		if err := validateTags(i, p.Sectors, p.Direction, p.Magnitude, p.Tickers); err != nil {
+			return err
+		}
+		if p.Confidence < 0 || p.Confidence > 1 {
+			return invalid(RuleConfidenceRange, "prediction %d has confidence %v outside [0, 1]", i, p.Confidence)
+		}
+		if strings.TrimSpace(p.Critique) == "" {
+			return invalid(RuleMissingCritique, "prediction %d missing critique", i)
+		}
+	}
+	return validateGraph(critiquedNodes(r.Predictions))
+}
+
+// CheckTickers returns an error if a prediction references a ticker outside allowed
+func (r CritiquedResponse) CheckTickers(allowed TickerSet) error {
+	for i, p := range r.Predictions {
+		if err := CheckTickers(p.Tickers, allowed); err != nil {
+			return invalid(RuleUnknownTicker, "prediction %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// CheckSources returns an error if a prediction cites a passage outside allowed
+func (r CritiquedResponse) CheckSources(allowed PassageSet) error {
+	for i, p := range r.Predictions {
+		if err := CheckSources(p.Sources, allowed); err != nil {
+			return invalid(RuleUnknownSource, "prediction %d: %v", i, err)
+		}
+	}
+	return nil
 }
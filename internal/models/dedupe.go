@@ -0,0 +1,17 @@
+package models
+
+// MergedPrediction is a near-duplicate folded into another prediction
+type MergedPrediction struct {
+	Timeframe   string `json:"timeframe"`
+	Description string `json:"description"`
+	// Similarity is the duplicate's score against the prediction it was merged into
+	Similarity float64 `json:"similarity"`
+}
+
+// Merge records near-duplicate predictions merged into one before the critique
+type Merge struct {
+	// Timeframe and Description are those of the merged prediction
+	Timeframe   string             `json:"timeframe"`
+	Description string             `json:"description"`
+	Duplicates  []MergedPrediction `json:"duplicates"`
+}
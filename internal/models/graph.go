@@ -0,0 +1,146 @@
+package models
+
+import "strings"
+
+// node is a prediction seen as a vertex of the causal graph
+type node struct {
+	ID      string
+	Parents []string
+}
+
+// validateGraph checks that the IDs of nodes are unique and that every parent names the
+// ID of another prediction without closing a cycle
+func validateGraph(nodes []node) error {
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		id := strings.TrimSpace(n.ID)
+		if id == "" {
+			if len(n.Parents) > 0 {
+				return invalid(RuleMissingField, "prediction %d has parents but no id", i)
+			}
+			continue
+		}
+		if _, ok := index[id]; ok {
+			return invalid(RuleDuplicateID, "prediction %d: duplicate id %q", i, id)
+		}
+		index[id] = i
+	}
+	for i, n := range nodes {
+		for _, parent := range n.Parents {
+			if _, ok := index[strings.TrimSpace(parent)]; !ok {
+				return invalid(RuleDanglingParent, "prediction %d: parent %q is not a prediction id", i, parent)
+			}
+		}
+	}
+	if _, err := topologicalOrder(nodes); err != nil {
+		return err
+	}
+	return nil
+}
+
+// topologicalOrder returns the indexes of nodes with every prediction after its parents.
+// Parents that name no prediction are ignored.
+func topologicalOrder(nodes []node) ([]int, error) {
+	index := make(map[string]int, len(nodes))
+	for i, n := range nodes {
+		if id := strings.TrimSpace(n.ID); id != "" {
+			index[id] = i
+		}
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make([]int, len(nodes))
+	order := make([]int, 0, len(nodes))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return invalid(RuleCycle, "prediction %q is its own ancestor", nodes[i].ID)
+		case done:
+			return nil
+		}
+		state[i] = visiting
+		for _, parent := range nodes[i].Parents {
+			if p, ok := index[strings.TrimSpace(parent)]; ok {
+				if err := visit(p); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = done
+		order = append(order, i)
+		return nil
+	}
+	for i := range nodes {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// predictionNodes returns the causal graph vertices of predictions
+func predictionNodes(predictions []Prediction) []node {
+	nodes := make([]node, len(predictions))
+	for i, p := range predictions {
+		nodes[i] = node{ID: p.ID, Parents: p.Parents}
+	}
+	return nodes
+}
+
+// critiquedNodes returns the causal graph vertices of critiqued predictions
+func critiquedNodes(predictions []CritiquedPrediction) []node {
+	nodes := make([]node, len(predictions))
+	for i, p := range predictions {
+		nodes[i] = node{ID: p.ID, Parents: p.Parents}
+	}
+	return nodes
+}
+
+// Likelihood returns the unconditional probability of the prediction: its propagated
+// Probability when it was set, else its Confidence
+func (p CritiquedPrediction) Likelihood() float64 {
+	if p.Probability > 0 {
+		return p.Probability
+	}
+	return p.Confidence
+}
+
+// HasGraph reports whether any prediction depends on another
+func (r CritiquedResponse) HasGraph() bool {
+	for _, p := range r.Predictions {
+		if len(p.Parents) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// PropagateConfidence sets the probability of every prediction from its confidence, read
+// as the probability it happens given that all its parents do. Parents are treated as
+// independent, so a prediction's probability is its confidence times the probabilities
+// of its parents; a prediction without parents keeps its confidence. The response must
+// pass Validate.
+func (r *CritiquedResponse) PropagateConfidence() error {
+	order, err := topologicalOrder(critiquedNodes(r.Predictions))
+	if err != nil {
+		return err
+	}
+	index := make(map[string]int, len(r.Predictions))
+	for i, p := range r.Predictions {
+		if id := strings.TrimSpace(p.ID); id != "" {
+			index[id] = i
+		}
+	}
+	for _, i := range order {
+		p := &r.Predictions[i]
+		p.Probability = p.Confidence
+		for _, parent := range p.Parents {
+			p.Probability *= r.Predictions[index[strings.TrimSpace(parent)]].Probability
+		}
+	}
+	return nil
+}
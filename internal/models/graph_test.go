@@ -0,0 +1,58 @@
+package models_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"testing"
+
+	"nostradamus/internal/models"
+)
+
+// causalResponse reads a graph of three chained predictions, listed out of order
+func causalResponse(t *testing.T) models.CritiquedResponse {
+	t.Helper()
+	data, err := os.ReadFile("testdata/causal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r models.CritiquedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestCausalGraphValidation(t *testing.T) {
+	r := causalResponse(t)
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Expected a valid graph, got: %v", err)
+	}
+	if err := r.PropagateConfidence(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, expected := range []float64{0.36, 0.8, 0.72} {
+		if math.Abs(r.Predictions[i].Probability-expected) > 1e-9 {
+			t.Errorf("Expected probability %v for %s, got %v", expected, r.Predictions[i].ID, r.Predictions[i].Probability)
+		}
+	}
+	if math.Abs(r.Predictions[0].Likelihood()-0.36) > 1e-9 {
+		t.Errorf("Expected the likelihood of a dependent prediction to be its overall probability, got %v", r.Predictions[0].Likelihood())
+	}
+
+	cases := map[string]func(*models.CritiquedResponse){
+		models.RuleDanglingParent: func(r *models.CritiquedResponse) { r.Predictions[0].Parents = []string{"p9"} },
+		models.RuleCycle:          func(r *models.CritiquedResponse) { r.Predictions[1].Parents = []string{"p3"} },
+		models.RuleDuplicateID:    func(r *models.CritiquedResponse) { r.Predictions[1].ID = "p2" },
+		models.RuleMissingField:   func(r *models.CritiquedResponse) { r.Predictions[0].ID = "" },
+	}
+	for rule, breakGraph := range cases {
+		r := causalResponse(t)
+		breakGraph(&r)
+		var validationErr *models.ValidationError
+		if err := r.Validate(); !errors.As(err, &validationErr) || validationErr.Rule != rule {
+			t.Errorf("Expected a %s error, got: %v", rule, err)
+		}
+	}
+}
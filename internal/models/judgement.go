@@ -0,0 +1,21 @@
+package models
+
+import "strings"
+
+// Judgement is an LLM judge's assessment of how well a run anticipated what happened
+type Judgement struct {
+	// Score runs from 0 (missed what happened) to 1 (anticipated it with fitting confidences)
+	Score     float64 `json:"score"`
+	Rationale string  `json:"rationale"`
+}
+
+// Validate checks that the score lies in [0, 1] and comes with a rationale
+func (j Judgement) Validate() error {
+	if j.Score < 0 || j.Score > 1 {
+		return invalid(RuleScoreRange, "judge score %v outside [0, 1]", j.Score)
+	}
+	if strings.TrimSpace(j.Rationale) == "" {
+		return invalid(RuleMissingField, "judgement missing rationale")
+	}
+	return nil
+}
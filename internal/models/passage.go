@@ -0,0 +1,38 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Passage is an excerpt of a research document retrieved for a run
+type Passage struct {
+	// ID identifies the passage as path#n, the nth passage of the document at path
+	ID     string  `json:"id"`
+	Source string  `json:"source"`
+	Text   string  `json:"text"`
+	Score  float64 `json:"score,omitempty"`
+}
+
+// PassageSet is a set of passage IDs predictions may cite
+type PassageSet map[string]bool
+
+// NewPassageSet returns the IDs of passages
+func NewPassageSet(passages []Passage) PassageSet {
+	set := make(PassageSet, len(passages))
+	for _, p := range passages {
+		set[p.ID] = true
+	}
+	return set
+}
+
+// CheckSources returns an error for the first source that is not in allowed.
+// A nil allowed set accepts no source.
+func CheckSources(sources []string, allowed PassageSet) error {
+	for _, s := range sources {
+		if !allowed[strings.TrimSpace(s)] {
+			return fmt.Errorf("unknown passage %q", s)
+		}
+	}
+	return nil
+}
@@ -1,10 +1,25 @@
 package models
 
+import "strings"
+
+// MaxPredictions is the largest number of predictions a response may contain
+const MaxPredictions = 10
+
 // Prediction represents a single event prediction
 type Prediction struct {
-	Timeframe   string `json:"timeframe"`
-	Description string `json:"description"`
-	Impact      string `json:"impact"`
+	// ID names the prediction so others can depend on it
+	ID string `json:"id,omitempty"`
+	// Parents are the IDs of the predictions that must happen for this one to follow
+	Parents     []string `json:"parents,omitempty"`
+	Timeframe   string   `json:"timeframe"`
+	Description string   `json:"description"`
+	Impact      string   `json:"impact"`
+	Sectors     []string `json:"sectors,omitempty"`
+	Direction   string   `json:"direction,omitempty"`
+	Magnitude   string   `json:"magnitude,omitempty"`
+	Tickers     []string `json:"tickers,omitempty"`
+	// Sources are the IDs of the retrieved passages the prediction relies on
+	Sources []string `json:"sources,omitempty"`
 }
 
 // PredictionResponse represents the initial predictions response
@@ -12,3 +27,47 @@ type PredictionResponse struct {
 	OriginalPrompt string       `json:"original_prompt"`
 	Predictions    []Prediction `json:"predictions"`
 }
+
+// Validate checks that the response holds between 1 and MaxPredictions complete predictions
+// whose tags, when present, belong to the bundled taxonomies, and whose parents form a
+// causal graph without cycles or dangling references
+func (r PredictionResponse) Validate() error {
+	if len(r.Predictions) == 0 || len(r.Predictions) > MaxPredictions {
+		return invalid(RulePredictionCount, "expected between 1 and %d predictions, got %d", MaxPredictions, len(r.Predictions))
+	}
+	for i, p := range r.Predictions {
+		if strings.TrimSpace(p.Timeframe) == "" || strings.TrimSpace(p.Description) == "" || strings.TrimSpace(p.Impact) == "" {
+			return invalid(RuleMissingField, "prediction %d missing one or more fields", i)
+		}
+		if err := validateTags(i, p.Sectors, p.Direction, p.Magnitude, p.Tickers); err != nil {
+			return err
+		}
+	}
+	return r.ValidateGraph()
+}
+
+// ValidateGraph checks that prediction IDs are unique and that parents name other
+// predictions without closing a cycle
+func (r PredictionResponse) ValidateGraph() error {
+	return validateGraph(predictionNodes(r.Predictions))
+}
+
+// CheckTickers returns an error if a prediction references a ticker outside allowed
+func (r PredictionResponse) CheckTickers(allowed TickerSet) error {
+	for i, p := range r.Predictions {
+		if err := CheckTickers(p.Tickers, allowed); err != nil {
+			return invalid(RuleUnknownTicker, "prediction %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// CheckSources returns an error if a prediction cites a passage outside allowed
+func (r PredictionResponse) CheckSources(allowed PassageSet) error {
+	for i, p := range r.Predictions {
+		if err := CheckSources(p.Sources, allowed); err != nil {
+			return invalid(RuleUnknownSource, "prediction %d: %v", i, err)
+		}
+	}
+	return nil
+}
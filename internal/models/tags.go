@@ -0,0 +1,97 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Sectors is the bundled GICS sector taxonomy predictions are tagged with
+var Sectors = []string{
+	"Energy",
+	"Materials",
+	"Industrials",
+	"Consumer Discretionary",
+	"Consumer Staples",
+	"Health Care",
+	"Financials",
+	"Information Technology",
+	"Communication Services",
+	"Utilities",
+	"Real Estate",
+}
+
+// Directions are the expected market reactions a prediction can be tagged with
+var Directions = []string{"bullish", "bearish", "volatile"}
+
+// Magnitudes are the buckets for the size of a prediction's market impact
+var Magnitudes = []string{"low", "medium", "high"}
+
+// TickerSet is a set of upper-case ticker symbols predictions may reference
+type TickerSet map[string]bool
+
+// ParseTickers reads ticker symbols separated by newlines, commas or spaces.
+// Empty lines and lines starting with # are ignored.
+func ParseTickers(r io.Reader) (TickerSet, error) {
+	tickers := make(TickerSet)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			tickers[strings.ToUpper(field)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// CheckTickers returns an error for the first ticker that is not in allowed.
+// A nil or empty allowed set accepts any ticker.
+func CheckTickers(tickers []string, allowed TickerSet) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, t := range tickers {
+		if !allowed[strings.ToUpper(t)] {
+			return fmt.Errorf("unknown ticker %q", t)
+		}
+	}
+	return nil
+}
+
+// validateTags checks the optional tag fields of the prediction at index against the bundled taxonomies
+func validateTags(index int, sectors []string, direction, magnitude string, tickers []string) error {
+	for _, s := range sectors {
+		if !containsFold(Sectors, s) {
+			return invalid(RuleUnknownSector, "prediction %d: unknown sector %q", index, s)
+		}
+	}
+	if direction != "" && !containsFold(Directions, direction) {
+		return invalid(RuleUnknownDirection, "prediction %d: unknown direction %q", index, direction)
+	}
+	if magnitude != "" && !containsFold(Magnitudes, magnitude) {
+		return invalid(RuleUnknownMagnitude, "prediction %d: unknown magnitude %q", index, magnitude)
+	}
+	for _, t := range tickers {
+		if strings.TrimSpace(t) == "" {
+			return invalid(RuleEmptyTicker, "prediction %d: empty ticker", index)
+		}
+	}
+	return nil
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+)
+
+func TestParseTickers(t *testing.T) {
+	tickers, err := models.ParseTickers(strings.NewReader("# watchlist\naapl, MSFT\n\nXOM\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse tickers: %v", err)
+	}
+	for _, ticker := range []string{"AAPL", "MSFT", "XOM"} {
+		if !tickers[ticker] {
+			t.Errorf("Expected ticker %s in set, got: %v", ticker, tickers)
+		}
+	}
+	if len(tickers) != 3 {
+		t.Errorf("Expected 3 tickers, got: %d", len(tickers))
+	}
+}
+
+func TestPredictionTagValidation(t *testing.T) {
+	valid := models.PredictionResponse{
+		OriginalPrompt: "test event",
+		Predictions: []models.Prediction{{
+			Timeframe: "1 week", Description: "Event A", Impact: "Market volatility",
+			Sectors: []string{"energy", "Utilities"}, Direction: "Bearish", Magnitude: "high", Tickers: []string{"xom"},
+		}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected tags to be valid, got: %v", err)
+	}
+	if err := valid.CheckTickers(models.TickerSet{"XOM": true}); err != nil {
+		t.Errorf("Expected XOM to be an allowed ticker, got: %v", err)
+	}
+	if err := valid.CheckTickers(models.TickerSet{"AAPL": true}); err == nil {
+		t.Error("Expected error for ticker outside the allowed list, got nil")
+	}
+
+	invalid := valid
+	invalid.Predictions = []models.Prediction{valid.Predictions[0]}
+	invalid.Predictions[0].Sectors = []string{"Crypto"}
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected error for unknown sector, got nil")
+	}
+	invalid.Predictions[0].Sectors = nil
+	invalid.Predictions[0].Direction = "sideways"
+	if err := invalid.Validate(); err == nil {
+		t.Error("Expected error for unknown direction, got nil")
+	}
+}
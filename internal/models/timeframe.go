@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Timeframe is a parsed prediction timeframe such as "6 months"
+type Timeframe struct {
+	Amount float64
+	// Unit is one of "day", "week", "month", "quarter" or "year"
+	Unit string
+}
+
+// timeframePattern matches an amount followed by a unit, optionally as the start of a range like "2-3 months"
+var timeframePattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(?:(?:-|to)\s*\d+(?:\.\d+)?\s*)?(day|week|month|quarter|year)s?\b`)
+
+// ParseTimeframe parses timeframes given as "X {days, weeks, months, quarters, years}".
+// For ranges such as "2-3 months" the start of the range is used.
+func ParseTimeframe(s string) (Timeframe, error) {
+	match := timeframePattern.FindStringSubmatch(strings.ToLower(s))
+	if match == nil {
+		return Timeframe{}, fmt.Errorf("unrecognised timeframe %q", s)
+	}
+	amount, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return Timeframe{}, fmt.Errorf("unrecognised timeframe %q: %w", s, err)
+	}
+	return Timeframe{Amount: amount, Unit: match[2]}, nil
+}
+
+// From returns the date the timeframe resolves to when counted from start
+func (t Timeframe) From(start time.Time) time.Time {
+	switch t.Unit {
+	case "day":
+		return start.AddDate(0, 0, int(math.Round(t.Amount)))
+	case "week":
+		return start.AddDate(0, 0, int(math.Round(t.Amount*7)))
+	case "month":
+		whole := math.Floor(t.Amount)
+		return start.AddDate(0, int(whole), int(math.Round((t.Amount-whole)*30.44)))
+	case "quarter":
+		return Timeframe{Amount: t.Amount * 3, Unit: "month"}.From(start)
+	default:
+		return Timeframe{Amount: t.Amount * 12, Unit: "month"}.From(start)
+	}
+}
+
+// Days returns the approximate length of the timeframe in days, for ordering
+func (t Timeframe) Days() float64 {
+	switch t.Unit {
+	case "day":
+		return t.Amount
+	case "week":
+		return t.Amount * 7
+	case "month":
+		return t.Amount * 30.44
+	case "quarter":
+		return t.Amount * 91.31
+	default:
+		return t.Amount * 365.25
+	}
+}
@@ -0,0 +1,33 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+func TestParseTimeframe(t *testing.T) {
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"2 weeks":        time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC),
+		"6 Months":       time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+		"2-3 years":      time.Date(2027, 1, 31, 0, 0, 0, 0, time.UTC),
+		"1 quarter":      time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		"1.5 years":      time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC),
+		"within 10 days": time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+	}
+	for input, expected := range cases {
+		tf, err := models.ParseTimeframe(input)
+		if err != nil {
+			t.Errorf("Failed to parse timeframe %q: %v", input, err)
+			continue
+		}
+		if got := tf.From(start); !got.Equal(expected) {
+			t.Errorf("Timeframe %q: expected %s, got %s", input, expected.Format("2006-01-02"), got.Format("2006-01-02"))
+		}
+	}
+	if _, err := models.ParseTimeframe("soon"); err == nil {
+		t.Error("Expected error for timeframe without a unit, got nil")
+	}
+}
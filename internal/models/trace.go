@@ -0,0 +1,15 @@
+package models
+
+// DebateRound records the critiqued predictions produced by one predictor/critic round
+type DebateRound struct {
+	Round           int                   `json:"round"`
+	Predictions     []CritiquedPrediction `json:"predictions"`
+	ConfidenceShift float64               `json:"confidence_shift"`
+}
+
+// RunTrace records how the predictions evolved over the course of a run
+type RunTrace struct {
+	Rounds     []DebateRound `json:"rounds,omitempty"`
+	Stabilised bool          `json:"stabilised,omitempty"`
+	Samples    int           `json:"samples,omitempty"`
+}
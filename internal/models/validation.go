@@ -0,0 +1,40 @@
+package models
+
+import "fmt"
+
+// Validation rules reported by ValidationError
+const (
+	RuleInvalidJSON      = "invalid_json"
+	RulePredictionCount  = "prediction_count"
+	RuleMissingField     = "missing_field"
+	RuleConfidenceRange  = "confidence_range"
+	RuleMissingCritique  = "missing_critique"
+	RuleUnknownSector    = "unknown_sector"
+	RuleUnknownDirection = "unknown_direction"
+	RuleUnknownMagnitude = "unknown_magnitude"
+	RuleEmptyTicker      = "empty_ticker"
+	RuleUnknownTicker    = "unknown_ticker"
+	RuleUnknownSource    = "unknown_source"
+	RuleDuplicateID      = "duplicate_id"
+	RuleDanglingParent   = "dangling_parent"
+	RuleCycle            = "cycle"
+	RuleConflictKind     = "conflict_kind"
+	RuleConflictIndex    = "conflict_index"
+	RuleScoreRange       = "score_range"
+)
+
+// ValidationError reports which rule of the output structure a response broke
+type ValidationError struct {
+	Rule    string
+	Message string
+}
+
+// Error returns the message describing the broken rule
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid builds a ValidationError for rule with a formatted message
+func invalid(rule, format string, args ...any) error {
+	return &ValidationError{Rule: rule, Message: fmt.Sprintf(format, args...)}
+}
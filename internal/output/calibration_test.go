@@ -0,0 +1,28 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func TestCalibrationRendering(t *testing.T) {
+	raw := 0.8
+	r := models.CritiquedResponse{
+		OriginalPrompt: "x",
+		Predictions:    []models.CritiquedPrediction{{Timeframe: "1 month", Description: "Event A", Impact: "Volatility", Confidence: 0.48, RawConfidence: &raw, Critique: "Plausible"}},
+		Calibration:    &models.Calibration{Method: "isotonic", Persona: "bear", Records: 31},
+	}
+
+	var md bytes.Buffer
+	if err := output.Render(&md, "markdown", r); err != nil || !strings.Contains(md.String(), "(calibrated from 80%)") {
+		t.Errorf("Expected the raw confidence in the Markdown report, got: %s", md.String())
+	}
+	var html bytes.Buffer
+	if err := output.Render(&html, "html", r); err != nil || !strings.Contains(html.String(), "48% (calibrated from 80%)") || !strings.Contains(html.String(), "Confidences calibrated by isotonic on 31 resolved predictions") {
+		t.Errorf("Expected the calibration in the HTML report, got: %s", html.String())
+	}
+}
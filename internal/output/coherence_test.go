@@ -0,0 +1,30 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func TestConflictRendering(t *testing.T) {
+	r := models.CritiquedResponse{
+		OriginalPrompt: "x",
+		Predictions: []models.CritiquedPrediction{
+			{Timeframe: "1 month", Description: "US issues ultimatum", Impact: "Defence rallies", Confidence: 0.7, Critique: "Likely"},
+			{Timeframe: "1 month", Description: "China withdraws troops", Impact: "Relief rally", Confidence: 0.6, Critique: "Plausible"},
+		},
+		Conflicts: []models.Conflict{{Kind: "mutually_exclusive", Predictions: []int{0, 1}, Explanation: "A withdrawal removes the need for an ultimatum", Total: 1.3, Inconsistent: true}},
+	}
+
+	var md bytes.Buffer
+	if err := output.Render(&md, "markdown", r); err != nil || !strings.Contains(md.String(), "- mutually exclusive between predictions 1, 2, combined confidence 130% (inconsistent): A withdrawal") {
+		t.Errorf("Expected the conflict in the Markdown report, got: %s", md.String())
+	}
+	var html bytes.Buffer
+	if err := output.Render(&html, "html", r); err != nil || !strings.Contains(html.String(), "mutually exclusive</span>, combined confidence 130% (inconsistent): A withdrawal") || !strings.Contains(html.String(), "<li>1 month: China withdraws troops</li>") {
+		t.Errorf("Expected the conflict and its predictions in the HTML report, got: %s", html.String())
+	}
+}
@@ -0,0 +1,40 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// renderCSV writes one row per prediction, with list fields joined by semicolons
+func renderCSV(w io.Writer, r models.CritiquedResponse) error {
+	writer := csv.NewWriter(w)
+	header := []string{"original_prompt", "timeframe", "description", "impact", "sectors", "direction", "magnitude", "tickers", "confidence", "critique", "frequency", "sources"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, p := range r.Predictions {
+		record := []string{
+			r.OriginalPrompt,
+			p.Timeframe,
+			p.Description,
+			p.Impact,
+			strings.Join(p.Sectors, ";"),
+			p.Direction,
+			p.Magnitude,
+			strings.Join(p.Tickers, ";"),
+			strconv.FormatFloat(p.Confidence, 'f', -1, 64),
+			p.Critique,
+			strconv.FormatFloat(p.Frequency, 'f', -1, 64),
+			strings.Join(p.Sources, ";"),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,33 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func TestMergeRendering(t *testing.T) {
+	r := models.CritiquedResponse{
+		OriginalPrompt: "x",
+		Predictions: []models.CritiquedPrediction{
+			{Timeframe: "2 weeks", Description: "Oil prices rise sharply on supply fears Oil prices rise sharply", Impact: "Producers gain", Confidence: 0.7, Critique: "Likely"},
+		},
+		Merges: []models.Merge{{
+			Timeframe:   "2 weeks",
+			Description: "Oil prices rise sharply on supply fears",
+			Duplicates:  []models.MergedPrediction{{Timeframe: "1 month", Description: "Oil prices rise sharply", Similarity: 0.8}},
+		}},
+	}
+
+	var md bytes.Buffer
+	if err := output.Render(&md, "markdown", r); err != nil || !strings.Contains(md.String(), "## Merged duplicates") || !strings.Contains(md.String(), "  - Oil prices rise sharply (1 month), similarity 0.80") {
+		t.Errorf("Expected the merge in the Markdown report, got: %s", md.String())
+	}
+	var html bytes.Buffer
+	if err := output.Render(&html, "html", r); err != nil || !strings.Contains(html.String(), "<h2>Merged duplicates</h2>") || !strings.Contains(html.String(), "<li>Oil prices rise sharply (1 month), similarity 0.80") {
+		t.Errorf("Expected the merge in the HTML report, got: %s", html.String())
+	}
+}
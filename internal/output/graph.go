@@ -0,0 +1,90 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// renderDOT writes the causal graph of the predictions in Graphviz DOT, one node per
+// prediction filled by confidence and one edge from each parent to its consequence
+func renderDOT(w io.Writer, r models.CritiquedResponse) error {
+	var b strings.Builder
+	b.WriteString("digraph predictions {\n")
+	fmt.Fprintf(&b, "  label=%s;\n", dotQuote(r.OriginalPrompt))
+	b.WriteString("  labelloc=t;\n  rankdir=LR;\n  node [shape=box, style=\"rounded,filled\", fontcolor=white];\n")
+	ids := nodeIDs(r.Predictions)
+	for i, p := range r.Predictions {
+		fmt.Fprintf(&b, "  %s [label=%s, fillcolor=%s];\n", dotQuote(ids[i]), dotQuote(nodeLabel(ids[i], p, "\n")), dotQuote(string(confidenceColour(p.Likelihood()))))
+	}
+	for i, p := range r.Predictions {
+		for _, parent := range p.Parents {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(strings.TrimSpace(parent)), dotQuote(ids[i]))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderMermaid writes the causal graph of the predictions as a Mermaid flowchart
+func renderMermaid(w io.Writer, r models.CritiquedResponse) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := nodeIDs(r.Predictions)
+	nodes := make(map[string]string, len(ids))
+	for i, id := range ids {
+		nodes[id] = fmt.Sprintf("n%d", i+1)
+	}
+	for i, p := range r.Predictions {
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", nodes[ids[i]], mermaidEscape(nodeLabel(ids[i], p, "<br/>")))
+	}
+	for i, p := range r.Predictions {
+		for _, parent := range p.Parents {
+			if from, ok := nodes[strings.TrimSpace(parent)]; ok {
+				fmt.Fprintf(&b, "  %s --> %s\n", from, nodes[ids[i]])
+			}
+		}
+	}
+	for i, p := range r.Predictions {
+		fmt.Fprintf(&b, "  style %s fill:%s,color:#fff\n", nodes[ids[i]], confidenceColour(p.Likelihood()))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// nodeIDs returns the ID of each prediction, numbering those without one as p1, p2...
+// after their position
+func nodeIDs(predictions []models.CritiquedPrediction) []string {
+	ids := make([]string, len(predictions))
+	for i, p := range predictions {
+		ids[i] = strings.TrimSpace(p.ID)
+		if ids[i] == "" {
+			ids[i] = fmt.Sprintf("p%d", i+1)
+		}
+	}
+	return ids
+}
+
+// nodeLabel describes a prediction on lines joined by sep: its ID and timeframe, the
+// first sentence of its description and its confidence, conditional when it has parents
+func nodeLabel(id string, p models.CritiquedPrediction, sep string) string {
+	confidence := fmt.Sprintf("%.0f%%", p.Confidence*100)
+	if len(p.Parents) > 0 {
+		confidence = fmt.Sprintf("%.0f%% given parents, %.0f%% overall", p.Confidence*100, p.Probability*100)
+	}
+	return strings.Join([]string{id + " · " + p.Timeframe, firstSentence(p.Description), confidence}, sep)
+}
+
+// dotQuote quotes s as a DOT string, keeping newlines as line breaks
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// mermaidEscape replaces the characters that would end a quoted Mermaid label
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s)
+}
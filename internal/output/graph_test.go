@@ -0,0 +1,64 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func TestCausalGraphExport(t *testing.T) {
+	data, err := os.ReadFile("../models/testdata/causal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r models.CritiquedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PropagateConfidence(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var dot bytes.Buffer
+	if err := output.Render(&dot, "dot", r); err != nil {
+		t.Fatalf("Failed to render DOT: %v", err)
+	}
+	for _, expected := range []string{
+		"digraph predictions {\n",
+		`  "p1" -> "p2";`,
+		`  "p2" -> "p3";`,
+		`"p2" [label="p2 · 1 month\nEnergy prices rise \"sharply\"\n90% given parents, 72% overall"`,
+		`"p3" [label="p3 · 3 months\nAirline margins shrink\n50% given parents, 36% overall"`,
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("Expected DOT to contain %q, got: %s", expected, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := output.Render(&mermaid, "mermaid", r); err != nil {
+		t.Fatalf("Failed to render Mermaid: %v", err)
+	}
+	for _, expected := range []string{
+		"flowchart LR\n",
+		`  n2["p1 · 2 weeks<br/>Gas supply to Europe drops<br/>80%"]`,
+		`  n3["p2 · 1 month<br/>Energy prices rise #quot;sharply#quot;<br/>90% given parents, 72% overall"]`,
+		"  n2 --> n3\n",
+		"  n3 --> n1\n",
+		"  style n1 fill:#",
+	} {
+		if !strings.Contains(mermaid.String(), expected) {
+			t.Errorf("Expected Mermaid to contain %q, got: %s", expected, mermaid.String())
+		}
+	}
+
+	var html bytes.Buffer
+	if err := output.Render(&html, "html", r); err != nil || !strings.Contains(html.String(), "Follows from p1 · 90% given its parents, 72% overall") {
+		t.Errorf("Expected the overall probability in the HTML report, got: %s", html.String())
+	}
+}
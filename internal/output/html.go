@@ -0,0 +1,155 @@
+package output
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// htmlTemplate is a self-contained report laying the predictions out on a timeline
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"colour":  confidenceColour,
+	"percent": func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+	"join":    strings.Join,
+	"kind":    func(kind string) string { return strings.ReplaceAll(kind, "_", " ") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Nostradamus: {{.OriginalPrompt}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 860px; margin: 2em auto; color: #222; }
+h1 { font-size: 1.4em; }
+.timeline { border-left: 3px solid #ccc; margin-left: 1em; padding-left: 1.5em; }
+.prediction { position: relative; margin-bottom: 1.5em; padding: 0.8em 1em; border-radius: 6px; border: 1px solid #ddd; border-left-width: 8px; }
+.prediction::before { content: ""; position: absolute; left: -2.05em; top: 1em; width: 0.9em; height: 0.9em; border-radius: 50%; background: inherit; border: 2px solid #fff; }
+.timeframe { font-weight: bold; font-size: 1.1em; }
+.confidence { float: right; font-weight: bold; }
+.tags { color: #555; font-size: 0.9em; }
+.label { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Predictions for: {{.OriginalPrompt}}</h1>
+{{- if .AsOf}}
+<p class="tags">As of {{.AsOf}}</p>
+{{- end}}
+{{- with .Calibration}}
+<p class="tags">Confidences calibrated by {{.Method}} on {{.Records}} resolved predictions</p>
+{{- end}}
+<div class="timeline">
+{{- range .Predictions}}
+<div class="prediction" style="border-left-color: {{colour .Confidence}}; background: {{colour .Confidence}}1a">
+<div><span class="timeframe">{{.Timeframe}}</span>{{if .ID}} <span class="tags">{{.ID}}</span>{{end}}<span class="confidence" style="color: {{colour .Confidence}}">{{percent .Confidence}}{{with .RawConfidence}} (calibrated from {{percent .}}){{end}}</span></div>
+{{- if .Parents}}
+<div class="tags">Follows from {{join .Parents ", "}} · {{percent .Confidence}} given its parents, {{percent .Probability}} overall</div>
+{{- end}}
+{{- if or .Sectors .Direction .Magnitude .Tickers}}
+<div class="tags">{{join .Sectors ", "}}{{if .Direction}} · {{.Direction}}{{end}}{{if .Magnitude}} · {{.Magnitude}} magnitude{{end}}{{if .Tickers}} · {{join .Tickers ", "}}{{end}}</div>
+{{- end}}
+<p>{{.Description}}</p>
+<p><span class="label">Impact:</span> {{.Impact}}</p>
+<p><span class="label">Critique:</span> {{.Critique}}</p>
+{{- if .Sources}}
+<p class="tags">Sources: {{join .Sources ", "}}</p>
+{{- end}}
+{{- if .Frequency}}
+<p class="tags">Appeared in {{percent .Frequency}} of samples</p>
+{{- end}}
+</div>
+{{- end}}
+</div>
+{{- if .Conflicts}}
+<h2>Conflicts</h2>
+<ul>
+{{- range .Conflicts}}
+<li><span class="label">{{kind .Kind}}</span>, combined confidence {{percent .Total}}{{if .Normalised}} (normalised){{else if .Inconsistent}} (inconsistent){{end}}: {{.Explanation}}
+<ul>
+{{- range .Members}}
+<li>{{.Timeframe}}: {{.Description}}</li>
+{{- end}}
+</ul>
+</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Merges}}
+<h2>Merged duplicates</h2>
+<ul>
+{{- range .Merges}}
+<li><span class="label">{{.Description}}</span> ({{.Timeframe}}) merged:
+<ul>
+{{- range .Duplicates}}
+<li>{{.Description}} ({{.Timeframe}}), similarity {{printf "%.2f" .Similarity}}</li>
+{{- end}}
+</ul>
+</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Analogs}}
+<h2>Historical analogs</h2>
+<ul>
+{{- range .Analogs}}
+<li><span class="label">{{.Event}}</span> ({{.Date}}): {{range $i, $o := .Outcomes}}{{if $i}}, {{end}}{{$o.Sector}} {{$o.Direction}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Passages}}
+<h2>Research passages</h2>
+<dl>
+{{- range .Passages}}
+<dt>{{.ID}}</dt>
+<dd>{{.Text}}</dd>
+{{- end}}
+</dl>
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlReport is a response whose conflicts list the predictions they group, since the
+// timeline reorders the predictions their indexes refer to
+type htmlReport struct {
+	models.CritiquedResponse
+	Conflicts []htmlConflict
+}
+
+// htmlConflict is a conflict with the predictions of the group
+type htmlConflict struct {
+	models.Conflict
+	Members []models.CritiquedPrediction
+}
+
+// renderHTML writes a self-contained HTML report with predictions sorted on a timeline
+// and coloured by confidence
+func renderHTML(w io.Writer, r models.CritiquedResponse) error {
+	report := htmlReport{CritiquedResponse: r}
+	for _, c := range r.Conflicts {
+		conflict := htmlConflict{Conflict: c}
+		for _, i := range c.Predictions {
+			if i >= 0 && i < len(r.Predictions) {
+				conflict.Members = append(conflict.Members, r.Predictions[i])
+			}
+		}
+		report.Conflicts = append(report.Conflicts, conflict)
+	}
+	report.Predictions = byTimeframe(r.Predictions)
+	return htmlTemplate.Execute(w, report)
+}
+
+// confidenceColour maps a confidence from 0 to 1 onto a red to green hex colour
+func confidenceColour(confidence float64) template.CSS {
+	if confidence < 0 {
+		confidence = 0
+	}
+	if confidence > 1 {
+		confidence = 1
+	}
+	red := int(220 * (1 - confidence))
+	green := int(160 * confidence)
+	return template.CSS(fmt.Sprintf("#%02x%02x40", red+20, green+40))
+}
@@ -0,0 +1,112 @@
+package output
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// icsDate is the iCalendar DATE value format
+const icsDate = "20060102"
+
+// RenderICS writes an iCalendar file with one all-day event per prediction on the date its
+// timeframe resolves to, counted from start, with a reminder on the morning of that day.
+// Predictions whose timeframe cannot be parsed are skipped, and their indexes returned
+// for the caller to report.
+func RenderICS(w io.Writer, r models.CritiquedResponse, start time.Time) ([]int, error) {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Nostradamus//Predictions//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICS("Nostradamus: "+r.OriginalPrompt))
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	var skipped []int
+	for i, p := range r.Predictions {
+		tf, err := models.ParseTimeframe(p.Timeframe)
+		if err != nil {
+			skipped = append(skipped, i)
+			continue
+		}
+		due := tf.From(start)
+
+		var details strings.Builder
+		fmt.Fprintf(&details, "Event: %s\n", r.OriginalPrompt)
+		fmt.Fprintf(&details, "Predicted on %s for %s.\n\n", start.Format("2006-01-02"), p.Timeframe)
+		fmt.Fprintf(&details, "%s\n\n", p.Description)
+		fmt.Fprintf(&details, "Impact: %s\n", p.Impact)
+		fmt.Fprintf(&details, "Confidence: %.0f%%\n", p.Confidence*100)
+		fmt.Fprintf(&details, "Critique: %s", p.Critique)
+
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+eventUID(r.OriginalPrompt, start, i))
+		writeICSLine(&b, "DTSTAMP:"+stamp)
+		writeICSLine(&b, "DTSTART;VALUE=DATE:"+due.Format(icsDate))
+		writeICSLine(&b, "DTEND;VALUE=DATE:"+due.AddDate(0, 0, 1).Format(icsDate))
+		writeICSLine(&b, "SUMMARY:"+escapeICS(fmt.Sprintf("Resolve prediction (%.0f%%): %s", p.Confidence*100, firstSentence(p.Description))))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICS(details.String()))
+		if len(p.Sectors) > 0 {
+			categories := make([]string, len(p.Sectors))
+			for j, s := range p.Sectors {
+				categories[j] = escapeICS(s)
+			}
+			writeICSLine(&b, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "BEGIN:VALARM")
+		writeICSLine(&b, "ACTION:DISPLAY")
+		writeICSLine(&b, "DESCRIPTION:"+escapeICS("Time to resolve a Nostradamus prediction"))
+		writeICSLine(&b, "TRIGGER;RELATED=START:PT9H")
+		writeICSLine(&b, "END:VALARM")
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return skipped, err
+}
+
+// writeICSLine writes a content line terminated by CRLF, folding it so that no
+// line exceeds 75 octets as required by RFC 5545
+func writeICSLine(b *strings.Builder, line string) {
+	const limit = 75
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
+
+// escapeICS escapes a TEXT value as required by RFC 5545
+func escapeICS(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// eventUID derives a stable identifier for a prediction so re-importing a run updates its events
+func eventUID(prompt string, start time.Time, index int) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d", prompt, start.Format(icsDate), index)))
+	return fmt.Sprintf("%x@nostradamus", sum[:10])
+}
+
+// firstSentence returns the first sentence of s, shortened to keep calendar titles readable
+func firstSentence(s string) string {
+	if i := strings.Index(s, ". "); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	if runes := []rune(s); len(runes) > 80 {
+		s = string(runes[:77]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func TestRenderICS(t *testing.T) {
+	r := sampleCritiquedResponse()
+	r.Predictions = append(r.Predictions, models.CritiquedPrediction{Timeframe: "someday", Description: "Event C", Impact: "Unclear", Confidence: 0.2, Critique: "Vague"})
+	var out bytes.Buffer
+	skipped, err := output.RenderICS(&out, r, time.Date(2025, time.January, 31, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("Expected calendar export, got error: %v", err)
+	}
+	ics := out.String()
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART;VALUE=DATE:20260131\r\n",
+		"DTSTART;VALUE=DATE:20250214\r\n",
+		"CATEGORIES:Energy\r\n",
+		"BEGIN:VALARM\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("Expected calendar to contain %q, got: %s", expected, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 2 || len(skipped) != 1 || skipped[0] != 2 {
+		t.Errorf("Expected 2 events and the prediction without a timeframe reported as skipped, got %v in: %s", skipped, ics)
+	}
+	if !strings.Contains(ics, `Banks | insurers fall\nConfidence: 30%`) {
+		t.Errorf("Expected escaped description with impact and confidence, got: %s", ics)
+	}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Expected lines folded at 75 octets, got %d: %q", len(line), line)
+		}
+	}
+}
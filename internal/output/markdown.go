@@ -0,0 +1,110 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// renderMarkdown writes a summary table followed by one section per prediction
+func renderMarkdown(w io.Writer, r models.CritiquedResponse) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Predictions for: %s\n\n", r.OriginalPrompt)
+	if r.AsOf != "" {
+		fmt.Fprintf(&b, "As of %s.\n\n", r.AsOf)
+	}
+	if c := r.Calibration; c != nil {
+		fmt.Fprintf(&b, "Confidences calibrated by %s on %d resolved predictions.\n\n", c.Method, c.Records)
+	}
+	b.WriteString("| # | Timeframe | Confidence | Direction | Sectors | Impact |\n")
+	b.WriteString("|---|-----------|------------|-----------|---------|--------|\n")
+	for i, p := range r.Predictions {
+		fmt.Fprintf(&b, "| %d | %s | %.0f%% | %s | %s | %s |\n",
+			i+1, escapeCell(p.Timeframe), p.Confidence*100, escapeCell(p.Direction),
+			escapeCell(strings.Join(p.Sectors, ", ")), escapeCell(p.Impact))
+	}
+	for i, p := range r.Predictions {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, p.Timeframe)
+		if p.ID != "" {
+			fmt.Fprintf(&b, "**ID:** %s", p.ID)
+			if len(p.Parents) > 0 {
+				fmt.Fprintf(&b, " · **Follows from:** %s", strings.Join(p.Parents, ", "))
+			}
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "**Confidence:** %.0f%%", p.Confidence*100)
+		if p.RawConfidence != nil {
+			fmt.Fprintf(&b, " (calibrated from %.0f%%)", *p.RawConfidence*100)
+		}
+		if len(p.Parents) > 0 {
+			fmt.Fprintf(&b, " given its parents · **Probability:** %.0f%%", p.Probability*100)
+		}
+		if p.Frequency > 0 {
+			fmt.Fprintf(&b, " · **Frequency:** %.0f%%", p.Frequency*100)
+		}
+		if p.Magnitude != "" {
+			fmt.Fprintf(&b, " · **Magnitude:** %s", p.Magnitude)
+		}
+		if len(p.Tickers) > 0 {
+			fmt.Fprintf(&b, " · **Tickers:** %s", strings.Join(p.Tickers, ", "))
+		}
+		b.WriteString("\n\n")
+		fmt.Fprintf(&b, "%s\n\n", p.Description)
+		fmt.Fprintf(&b, "**Impact:** %s\n\n", p.Impact)
+		fmt.Fprintf(&b, "**Critique:** %s\n", p.Critique)
+		if len(p.Sources) > 0 {
+			fmt.Fprintf(&b, "\n**Sources:** %s\n", strings.Join(p.Sources, ", "))
+		}
+	}
+	if len(r.Conflicts) > 0 {
+		b.WriteString("\n## Conflicts\n\n")
+		for _, c := range r.Conflicts {
+			numbers := make([]string, len(c.Predictions))
+			for i, p := range c.Predictions {
+				numbers[i] = fmt.Sprintf("%d", p+1)
+			}
+			fmt.Fprintf(&b, "- %s between predictions %s, combined confidence %.0f%%", strings.ReplaceAll(c.Kind, "_", " "), strings.Join(numbers, ", "), c.Total*100)
+			if c.Normalised {
+				b.WriteString(" (normalised)")
+			} else if c.Inconsistent {
+				b.WriteString(" (inconsistent)")
+			}
+			fmt.Fprintf(&b, ": %s\n", c.Explanation)
+		}
+	}
+	if len(r.Merges) > 0 {
+		b.WriteString("\n## Merged duplicates\n\n")
+		for _, m := range r.Merges {
+			fmt.Fprintf(&b, "- **%s** (%s) merged:\n", firstSentence(m.Description), m.Timeframe)
+			for _, d := range m.Duplicates {
+				fmt.Fprintf(&b, "  - %s (%s), similarity %.2f\n", strings.Join(strings.Fields(d.Description), " "), d.Timeframe, d.Similarity)
+			}
+		}
+	}
+	if len(r.Analogs) > 0 {
+		b.WriteString("\n## Historical analogs\n\n")
+		for _, a := range r.Analogs {
+			reactions := make([]string, len(a.Outcomes))
+			for i, o := range a.Outcomes {
+				reactions[i] = o.Sector + " " + o.Direction
+			}
+			fmt.Fprintf(&b, "- **%s** (%s): %s\n", a.Event, a.Date, strings.Join(reactions, ", "))
+		}
+	}
+	if len(r.Passages) > 0 {
+		b.WriteString("\n## Research passages\n\n")
+		for _, passage := range r.Passages {
+			fmt.Fprintf(&b, "- **%s**: %s\n", passage.ID, strings.Join(strings.Fields(passage.Text), " "))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeCell keeps a value on a single Markdown table cell
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
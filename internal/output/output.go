@@ -0,0 +1,80 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{"json", "pretty", "markdown", "csv", "html", "ics", "dot", "mermaid"}
+
+// Render writes the critiqued predictions to w in the given format. The ics format leaves
+// out predictions with an unrecognised timeframe; use RenderICS to learn which.
+func Render(w io.Writer, format string, r models.CritiquedResponse) error {
+	switch strings.ToLower(format) {
+	case "", "json":
+		return renderJSON(w, r, false)
+	case "pretty":
+		return renderJSON(w, r, true)
+	case "markdown", "md":
+		return renderMarkdown(w, r)
+	case "csv":
+		return renderCSV(w, r)
+	case "html":
+		return renderHTML(w, r)
+	case "ics":
+		start, err := r.AsOfDate(time.Now())
+		if err != nil {
+			return err
+		}
+		_, err = RenderICS(w, r, start)
+		return err
+	case "dot":
+		return renderDOT(w, r)
+	case "mermaid":
+		return renderMermaid(w, r)
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// renderJSON writes the response as compact or indented JSON
+func renderJSON(w io.Writer, r models.CritiquedResponse, indent bool) error {
+	encoder := json.NewEncoder(w)
+	if indent {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(r)
+}
+
+// byTimeframe returns the predictions ordered from the nearest to the furthest timeframe.
+// Predictions whose timeframe cannot be parsed keep their relative order at the end.
+func byTimeframe(predictions []models.CritiquedPrediction) []models.CritiquedPrediction {
+	days := make(map[int]float64, len(predictions))
+	indexes := make([]int, len(predictions))
+	for i, p := range predictions {
+		indexes[i] = i
+		if tf, err := models.ParseTimeframe(p.Timeframe); err == nil {
+			days[i] = tf.Days()
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		dayA, okA := days[indexes[a]]
+		dayB, okB := days[indexes[b]]
+		if okA != okB {
+			return okA
+		}
+		return dayA < dayB
+	})
+	sorted := make([]models.CritiquedPrediction, len(predictions))
+	for i, idx := range indexes {
+		sorted[i] = predictions[idx]
+	}
+	return sorted
+}
@@ -0,0 +1,63 @@
+package output_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/output"
+)
+
+func sampleCritiquedResponse() models.CritiquedResponse {
+	return models.CritiquedResponse{
+		OriginalPrompt: "test event",
+		Predictions: []models.CritiquedPrediction{
+			{Timeframe: "1 year", Description: "Event B", Impact: "Banks | insurers fall", Confidence: 0.3, Critique: "Unlikely"},
+			{Timeframe: "2 weeks", Description: "Event A", Impact: "Energy rallies", Sectors: []string{"Energy"}, Direction: "bullish", Confidence: 0.9, Critique: "Likely"},
+		},
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	r := sampleCritiquedResponse()
+
+	var pretty bytes.Buffer
+	if err := output.Render(&pretty, "pretty", r); err != nil {
+		t.Fatalf("Failed to render pretty JSON: %v", err)
+	}
+	if !strings.Contains(pretty.String(), "\n  \"predictions\": [") {
+		t.Errorf("Expected indented JSON, got: %s", pretty.String())
+	}
+
+	var md bytes.Buffer
+	if err := output.Render(&md, "markdown", r); err != nil {
+		t.Fatalf("Failed to render Markdown: %v", err)
+	}
+	if !strings.Contains(md.String(), `| 1 | 1 year | 30% |  |  | Banks \| insurers fall |`) || !strings.Contains(md.String(), "## 2. 2 weeks") {
+		t.Errorf("Expected Markdown table and sections, got: %s", md.String())
+	}
+
+	var csvOut bytes.Buffer
+	if err := output.Render(&csvOut, "csv", r); err != nil {
+		t.Fatalf("Failed to render CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "original_prompt,timeframe,") || !strings.Contains(lines[2], "Energy,bullish") {
+		t.Errorf("Expected CSV header and two rows, got: %s", csvOut.String())
+	}
+
+	var html bytes.Buffer
+	if err := output.Render(&html, "html", r); err != nil {
+		t.Fatalf("Failed to render HTML: %v", err)
+	}
+	page := html.String()
+	if strings.Index(page, "Event A") > strings.Index(page, "Event B") {
+		t.Error("Expected HTML timeline to list the 2 weeks prediction before the 1 year one")
+	}
+
+	if err := output.Render(io.Discard, "yaml", r); err == nil {
+		t.Error("Expected error for unknown format, got nil")
+	}
+}
@@ -0,0 +1,101 @@
+package portfolio
+
+import (
+	"strings"
+
+	"nostradamus/internal/models"
+)
+
+// Exposure links a holding to a prediction that affects it. Confidence is the prediction's
+// unconditional probability, so a prediction following from others is not overweighted.
+type Exposure struct {
+	Prediction  int     `json:"prediction"`
+	Timeframe   string  `json:"timeframe"`
+	Description string  `json:"description"`
+	Direction   string  `json:"direction,omitempty"`
+	Confidence  float64 `json:"confidence"`
+	// MatchedBy is "ticker" when the prediction names the holding and "sector" when it only names its sector
+	MatchedBy string `json:"matched_by"`
+	// Weighted is the holding weight multiplied by Confidence
+	Weighted float64 `json:"weighted"`
+}
+
+// PositionReport lists the predictions affecting a single holding
+type PositionReport struct {
+	Holding
+	Exposures []Exposure `json:"exposures"`
+	// Net is the confidence-weighted exposure of bullish predictions minus bearish ones
+	Net float64 `json:"net"`
+	// Volatility is the confidence-weighted exposure of volatile predictions
+	Volatility float64 `json:"volatility"`
+}
+
+// Report is the confidence-weighted exposure of a portfolio to a set of critiqued predictions
+type Report struct {
+	OriginalPrompt string           `json:"original_prompt"`
+	Positions      []PositionReport `json:"positions"`
+	Net            float64          `json:"net"`
+	Volatility     float64          `json:"volatility"`
+	// Unmapped lists the predictions that affect none of the holdings
+	Unmapped []int `json:"unmapped,omitempty"`
+}
+
+// Analyse maps each critiqued prediction onto the holdings it affects, by ticker first
+// and by sector otherwise, and weights every exposure by the prediction's unconditional
+// probability, see models.CritiquedPrediction.Likelihood.
+func Analyse(holdings Portfolio, critiqued models.CritiquedResponse) Report {
+	report := Report{OriginalPrompt: critiqued.OriginalPrompt}
+	mapped := make([]bool, len(critiqued.Predictions))
+	for _, h := range holdings {
+		position := PositionReport{Holding: h, Exposures: []Exposure{}}
+		for i, p := range critiqued.Predictions {
+			matchedBy := match(h, p)
+			if matchedBy == "" {
+				continue
+			}
+			mapped[i] = true
+			exposure := Exposure{
+				Prediction:  i,
+				Timeframe:   p.Timeframe,
+				Description: p.Description,
+				Direction:   p.Direction,
+				Confidence:  p.Likelihood(),
+				MatchedBy:   matchedBy,
+				Weighted:    h.Weight * p.Likelihood(),
+			}
+			position.Exposures = append(position.Exposures, exposure)
+			switch strings.ToLower(p.Direction) {
+			case "bullish":
+				position.Net += exposure.Weighted
+			case "bearish":
+				position.Net -= exposure.Weighted
+			case "volatile":
+				position.Volatility += exposure.Weighted
+			}
+		}
+		report.Net += position.Net
+		report.Volatility += position.Volatility
+		report.Positions = append(report.Positions, position)
+	}
+	for i, ok := range mapped {
+		if !ok {
+			report.Unmapped = append(report.Unmapped, i)
+		}
+	}
+	return report
+}
+
+// match returns how a prediction maps onto a holding, or "" if it does not
+func match(h Holding, p models.CritiquedPrediction) string {
+	for _, t := range p.Tickers {
+		if strings.EqualFold(t, h.Ticker) {
+			return "ticker"
+		}
+	}
+	for _, s := range p.Sectors {
+		if strings.EqualFold(s, h.Sector) {
+			return "sector"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package portfolio_test
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/portfolio"
+)
+
+func TestExposureReport(t *testing.T) {
+	holdings, err := portfolio.Load(strings.NewReader("ticker,weight,sector\nXOM,0.3,Energy\nDAL,0.2,Industrials\nAAPL,0.5,Information Technology\n"))
+	if err != nil {
+		t.Fatalf("Failed to load portfolio: %v", err)
+	}
+	var critiqued models.CritiquedResponse
+	if err := json.Unmarshal([]byte(`{"original_prompt": "test event", "predictions": [
+		{"timeframe": "1 week", "description": "Oil spikes", "impact": "Energy rallies", "sectors": ["Energy"], "direction": "bullish", "confidence": 0.8, "critique": "Likely"},
+		{"timeframe": "3 months", "description": "Airlines struggle", "impact": "Airlines fall", "sectors": ["Industrials"], "direction": "bearish", "tickers": ["DAL"], "confidence": 0.5, "critique": "Possible"},
+		{"timeframe": "1 year", "description": "Banks wobble", "impact": "Financials swing", "sectors": ["Financials"], "direction": "volatile", "confidence": 0.4, "critique": "Unclear"}
+	]}`), &critiqued); err != nil {
+		t.Fatal(err)
+	}
+
+	report := portfolio.Analyse(holdings, critiqued)
+	if len(report.Positions) != 3 {
+		t.Fatalf("Expected 3 positions, got: %d", len(report.Positions))
+	}
+	xom := report.Positions[0]
+	if len(xom.Exposures) != 1 || xom.Exposures[0].MatchedBy != "sector" || math.Abs(xom.Net-0.24) > 1e-9 {
+		t.Errorf("Expected XOM to be bullish through its sector with net 0.24, got: %+v", xom)
+	}
+	dal := report.Positions[1]
+	if len(dal.Exposures) != 1 || dal.Exposures[0].MatchedBy != "ticker" || math.Abs(dal.Net+0.1) > 1e-9 {
+		t.Errorf("Expected DAL to be bearish through its ticker with net -0.1, got: %+v", dal)
+	}
+	if len(report.Positions[2].Exposures) != 0 {
+		t.Errorf("Expected AAPL to be unaffected, got: %+v", report.Positions[2])
+	}
+	if len(report.Unmapped) != 1 || report.Unmapped[0] != 2 {
+		t.Errorf("Expected the financials prediction to be unmapped, got: %v", report.Unmapped)
+	}
+}
+
+func TestDependentPredictionsWeightedByProbability(t *testing.T) {
+	r := models.CritiquedResponse{Predictions: []models.CritiquedPrediction{
+		{ID: "p1", Timeframe: "2 weeks", Description: "Gas supply to Europe drops", Confidence: 0.8},
+		{ID: "p2", Parents: []string{"p1"}, Timeframe: "3 months", Description: "Airline margins shrink", Sectors: []string{"Industrials"}, Direction: "bearish", Confidence: 0.5},
+	}}
+	if err := r.PropagateConfidence(); err != nil {
+		t.Fatal(err)
+	}
+	// p2 happens with probability 0.5 given p1, so 0.5 * 0.8 overall
+	want := 0.4
+	report := portfolio.Analyse(portfolio.Portfolio{{Ticker: "DAL", Weight: 0.5, Sector: "Industrials"}}, r)
+	exposure := report.Positions[0].Exposures[0]
+	if math.Abs(exposure.Confidence-want) > 1e-9 || math.Abs(exposure.Weighted-0.5*want) > 1e-9 {
+		t.Errorf("Expected the exposure weighted by the overall probability %v, got %+v", want, exposure)
+	}
+}
@@ -0,0 +1,71 @@
+package portfolio
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Holding is a single position of a portfolio
+type Holding struct {
+	Ticker string  `json:"ticker"`
+	Weight float64 `json:"weight"`
+	Sector string  `json:"sector"`
+}
+
+// Portfolio is the list of holdings predictions are mapped onto
+type Portfolio []Holding
+
+// Load reads a portfolio from CSV with a header row naming the "ticker", "weight"
+// and "sector" columns, in any order.
+func Load(r io.Reader) (Portfolio, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("portfolio is empty")
+		}
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"ticker", "weight", "sector"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("portfolio is missing the %q column", name)
+		}
+	}
+
+	var holdings Portfolio
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		weight, err := strconv.ParseFloat(strings.TrimSpace(record[columns["weight"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid weight: %w", line, err)
+		}
+		ticker := strings.ToUpper(strings.TrimSpace(record[columns["ticker"]]))
+		if ticker == "" {
+			return nil, fmt.Errorf("line %d: missing ticker", line)
+		}
+		holdings = append(holdings, Holding{
+			Ticker: ticker,
+			Weight: weight,
+			Sector: strings.TrimSpace(record[columns["sector"]]),
+		})
+	}
+	return holdings, nil
+}
@@ -0,0 +1,15 @@
+package portfolio_test
+
+import (
+	"strings"
+	"testing"
+
+	"nostradamus/internal/portfolio"
+)
+
+func TestLoadPortfolioMissingColumn(t *testing.T) {
+	_, err := portfolio.Load(strings.NewReader("ticker,weight\nXOM,0.3\n"))
+	if err == nil {
+		t.Error("Expected error for portfolio without a sector column, got nil")
+	}
+}
@@ -0,0 +1,100 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket refilled continuously at a per-minute rate. It is safe for
+// concurrent use; callers reserve tokens in arrival order and wait until their
+// reservation is covered.
+type Bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	// perSecond is the refill rate
+	perSecond float64
+	// tokens may go negative, recording reservations that are not covered yet
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket creates a full bucket holding perMinute tokens, refilled at perMinute tokens a minute
+func NewBucket(perMinute float64) *Bucket {
+	return &Bucket{
+		capacity:  perMinute,
+		perSecond: perMinute / 60,
+		tokens:    perMinute,
+		last:      time.Now(),
+	}
+}
+
+// Take reserves n tokens, blocks until they are available and returns the time spent waiting
+func (b *Bucket) Take(n float64) time.Duration {
+	wait := b.reserve(n)
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return wait
+}
+
+// Adjust corrects an earlier reservation once the real cost is known: a positive n takes
+// further tokens without waiting, so later callers wait for them, and a negative n
+// returns tokens that were reserved but not used
+func (b *Bucket) Adjust(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens -= n
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// reserve takes n tokens and returns how long the caller must wait for them to be refilled
+func (b *Bucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.perSecond * float64(time.Second))
+}
+
+// refill adds the tokens accrued since the last call, up to the capacity
+func (b *Bucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.perSecond
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// Semaphore bounds the number of operations running at once
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore creates a Semaphore admitting up to n concurrent holders
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until a slot is free and returns the time spent waiting
+func (s *Semaphore) Acquire() time.Duration {
+	select {
+	case s.slots <- struct{}{}:
+		return 0
+	default:
+	}
+	started := time.Now()
+	s.slots <- struct{}{}
+	return time.Since(started)
+}
+
+// Release frees a slot taken with Acquire
+func (s *Semaphore) Release() {
+	<-s.slots
+}
@@ -0,0 +1,50 @@
+package ratelimit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"nostradamus/internal/ratelimit"
+)
+
+func TestBucketWaitsForRefill(t *testing.T) {
+	bucket := ratelimit.NewBucket(6000)
+	if waited := bucket.Take(6000); waited != 0 {
+		t.Errorf("Expected a full bucket to serve its capacity at once, waited %v", waited)
+	}
+	started := time.Now()
+	waited := bucket.Take(5)
+	if waited < 40*time.Millisecond || time.Since(started) < 40*time.Millisecond {
+		t.Errorf("Expected to wait about 50ms for 5 tokens at 100/s, waited %v", waited)
+	}
+
+	bucket = ratelimit.NewBucket(6000)
+	bucket.Adjust(6000)
+	bucket.Adjust(-6000)
+	if waited := bucket.Take(6000); waited != 0 {
+		t.Errorf("Expected refunded tokens to be available again, waited %v", waited)
+	}
+}
+
+func TestSemaphoreBoundsHolders(t *testing.T) {
+	semaphore := ratelimit.NewSemaphore(1)
+	if waited := semaphore.Acquire(); waited != 0 {
+		t.Errorf("Expected a free slot without waiting, waited %v", waited)
+	}
+
+	var wg sync.WaitGroup
+	var waited time.Duration
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		waited = semaphore.Acquire()
+		semaphore.Release()
+	}()
+	time.Sleep(30 * time.Millisecond)
+	semaphore.Release()
+	wg.Wait()
+	if waited < 20*time.Millisecond {
+		t.Errorf("Expected the second holder to wait for the release, waited %v", waited)
+	}
+}
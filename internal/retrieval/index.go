@@ -0,0 +1,218 @@
+package retrieval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/similarity"
+)
+
+// BM25 parameters: k1 saturates term frequency and b normalises for passage length
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Extensions are the file extensions of the documents Build indexes
+var Extensions = []string{".txt", ".md", ".markdown"}
+
+// Posting records how often a term occurs in a passage
+type Posting struct {
+	Passage int `json:"passage"`
+	Freq    int `json:"freq"`
+}
+
+// Index is a BM25 inverted index over the passages of a document corpus
+type Index struct {
+	Passages []models.Passage `json:"passages"`
+	// Lengths is the number of tokens of each passage
+	Lengths  []int                `json:"lengths"`
+	Postings map[string][]Posting `json:"postings"`
+}
+
+// Build indexes the text and Markdown files under dir, split into passages of about
+// maxWords words at paragraph boundaries. Passage IDs use paths relative to dir.
+func Build(dir string, maxWords int) (*Index, error) {
+	idx := &Index{Postings: make(map[string][]Posting)}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !indexable(path) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for i, text := range Split(string(data), maxWords) {
+			idx.add(models.Passage{ID: fmt.Sprintf("%s#%d", rel, i+1), Source: rel, Text: text})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// indexable reports whether the file at path has one of Extensions
+func indexable(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// Split cuts text into passages of whole paragraphs holding up to maxWords words each.
+// A paragraph longer than maxWords is a passage on its own.
+func Split(text string, maxWords int) []string {
+	var passages []string
+	var current []string
+	words := 0
+	flush := func() {
+		if len(current) > 0 {
+			passages = append(passages, strings.Join(current, "\n\n"))
+			current, words = nil, 0
+		}
+	}
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		n := len(strings.Fields(paragraph))
+		if words > 0 && words+n > maxWords {
+			flush()
+		}
+		current = append(current, paragraph)
+		words += n
+	}
+	flush()
+	return passages
+}
+
+// add appends passage p to the index
+func (idx *Index) add(p models.Passage) {
+	id := len(idx.Passages)
+	tokens := similarity.Tokens(p.Text)
+	idx.Passages = append(idx.Passages, p)
+	idx.Lengths = append(idx.Lengths, len(tokens))
+
+	freqs := make(map[string]int)
+	for _, t := range tokens {
+		freqs[t]++
+	}
+	for t, n := range freqs {
+		idx.Postings[t] = append(idx.Postings[t], Posting{Passage: id, Freq: n})
+	}
+}
+
+// Search returns the k passages scoring highest for query under BM25, best first.
+// Passages sharing no term with query are never returned.
+func (idx *Index) Search(query string, k int) []models.Passage {
+	n := len(idx.Passages)
+	if n == 0 || k <= 0 {
+		return nil
+	}
+	total := 0
+	for _, l := range idx.Lengths {
+		total += l
+	}
+	avgLength := float64(total) / float64(n)
+
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, term := range similarity.Tokens(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		postings := idx.Postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(n)-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+		for _, posting := range postings {
+			tf := float64(posting.Freq)
+			norm := 1 - b + b*float64(idx.Lengths[posting.Passage])/avgLength
+			scores[posting.Passage] += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+	}
+
+	ranked := make([]int, 0, len(scores))
+	for id := range scores {
+		ranked = append(ranked, id)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if scores[ranked[i]] != scores[ranked[j]] {
+			return scores[ranked[i]] > scores[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	results := make([]models.Passage, len(ranked))
+	for i, id := range ranked {
+		results[i] = idx.Passages[id]
+		results[i].Score = scores[id]
+	}
+	return results
+}
+
+// Save writes the index as JSON to path
+func (idx *Index) Save(path string) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load reads an index written by Save, rejecting one whose lengths or postings do not
+// match its passages
+func Load(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, err
+	}
+	if len(idx.Lengths) != len(idx.Passages) {
+		return nil, fmt.Errorf("index has %d passage lengths for %d passages", len(idx.Lengths), len(idx.Passages))
+	}
+	for i, l := range idx.Lengths {
+		if l < 0 {
+			return nil, fmt.Errorf("index has negative length %d for passage %d", l, i)
+		}
+	}
+	for term, postings := range idx.Postings {
+		for _, posting := range postings {
+			if posting.Passage < 0 || posting.Passage >= len(idx.Passages) {
+				return nil, fmt.Errorf("index posting of %q refers to passage %d of %d", term, posting.Passage, len(idx.Passages))
+			}
+			if posting.Freq < 1 {
+				return nil, fmt.Errorf("index posting of %q has frequency %d in passage %d", term, posting.Freq, posting.Passage)
+			}
+		}
+	}
+	return &idx, nil
+}
@@ -0,0 +1,68 @@
+package retrieval_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/retrieval"
+)
+
+// notes holds a Markdown and a text note, and a PDF that is not indexed
+const notes = "testdata/notes"
+
+func TestBM25Index(t *testing.T) {
+	built, err := retrieval.Build(notes, 8)
+	if err != nil {
+		t.Fatalf("Failed to build index: %v", err)
+	}
+	if len(built.Passages) != 4 {
+		t.Errorf("Expected 4 passages from the text and Markdown files, got %+v", built.Passages)
+	}
+	indexPath := t.TempDir() + "/index.json"
+	if err := built.Save(indexPath); err != nil {
+		t.Fatalf("Failed to save index: %v", err)
+	}
+
+	idx, err := retrieval.Load(indexPath)
+	if err != nil {
+		t.Fatalf("Failed to load index: %v", err)
+	}
+	results := idx.Search("Deposit flight hits regional banks", 2)
+	if len(results) != 2 || results[0].ID != "banks.md#3" || results[1].ID != "banks.md#1" {
+		t.Fatalf("Expected the deposit flight passage first, got %+v", results)
+	}
+	if results[0].Score <= results[1].Score || results[0].Source != "banks.md" {
+		t.Errorf("Expected scored results best first, got %+v", results)
+	}
+	if results := idx.Search("crude oil production", 5); len(results) != 1 || results[0].ID != "energy/oil.txt#1" {
+		t.Errorf("Expected only the oil passage, got %+v", results)
+	}
+	if results := idx.Search("semiconductors", 5); len(results) != 0 {
+		t.Errorf("Expected no passage without a shared term, got %+v", results)
+	}
+
+	// A corrupt index is rejected instead of making Search panic
+	corrupt := t.TempDir() + "/corrupt.json"
+	for _, index := range []string{
+		`{"passages": [{"id": "a#1", "text": "banks"}], "lengths": [1], "postings": {"banks": [{"passage": 3, "freq": 1}]}}`,
+		`{"passages": [{"id": "a#1", "text": "banks"}], "lengths": [1], "postings": {"banks": [{"passage": -1, "freq": 1}]}}`,
+		`{"passages": [{"id": "a#1", "text": "banks"}], "lengths": [1], "postings": {"banks": [{"passage": 0, "freq": 0}]}}`,
+		`{"passages": [{"id": "a#1", "text": "banks"}], "lengths": [-1], "postings": {}}`,
+	} {
+		if err := os.WriteFile(corrupt, []byte(index), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := retrieval.Load(corrupt); err == nil {
+			t.Errorf("Expected %s to be rejected", index)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := "One two three.\r\n\r\nFour five.\n\n\n\nSix seven eight nine ten eleven."
+	passages := retrieval.Split(text, 5)
+	if len(passages) != 2 || passages[0] != "One two three.\n\nFour five." || !strings.HasPrefix(passages[1], "Six") {
+		t.Errorf("Expected whole paragraphs up to 5 words per passage, got %q", passages)
+	}
+}
@@ -0,0 +1,102 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Func scores how similar two texts are, from 0 (unrelated) to 1 (identical)
+type Func func(a, b string) float64
+
+// stopWords are common words ignored when comparing texts lexically
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"are": true, "was": true, "will": true, "may": true, "could": true, "might": true,
+	"its": true, "their": true, "from": true, "into": true, "over": true, "due": true,
+	"has": true, "have": true, "been": true, "more": true, "can": true, "than": true,
+}
+
+// Tokens splits a text into lowercase words, dropping short words and stop words
+func Tokens(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len(f) < 3 || stopWords[f] {
+			continue
+		}
+		tokens = append(tokens, f)
+	}
+	return tokens
+}
+
+// Lexical returns the Jaccard similarity of the token sets of a and b
+func Lexical(a, b string) float64 {
+	setA := make(map[string]bool)
+	for _, t := range Tokens(a) {
+		setA[t] = true
+	}
+	setB := make(map[string]bool)
+	for _, t := range Tokens(b) {
+		setB[t] = true
+	}
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	shared := 0
+	for t := range setA {
+		if setB[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// Cluster groups texts whose similarity to a cluster's first member is at least
+// threshold. Each cluster is returned as the indexes of its members, in input order.
+func Cluster(texts []string, sim Func, threshold float64) [][]int {
+	return Group(len(texts), func(i, j int) float64 {
+		return sim(texts[i], texts[j])
+	}, threshold)
+}
+
+// Group groups n items whose score against a group's first member is at least
+// threshold, where score(i, j) compares the items at indexes i and j. Each group is
+// returned as the indexes of its members, in input order.
+func Group(n int, score func(i, j int) float64, threshold float64) [][]int {
+	var groups [][]int
+	for i := 0; i < n; i++ {
+		placed := false
+		for g, members := range groups {
+			if score(members[0], i) >= threshold {
+				groups[g] = append(groups[g], i)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			groups = append(groups, []int{i})
+		}
+	}
+	return groups
+}
+
+// Cosine returns the cosine similarity of two vectors of the same length, or 0 when
+// either is zero or their lengths differ
+func Cosine(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
@@ -0,0 +1,56 @@
+package similarity_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"nostradamus/internal/similarity"
+)
+
+func TestLexical(t *testing.T) {
+	if got := similarity.Tokens("The Oil prices rise, on OPEC+ cuts"); !slices.Equal(got, []string{"oil", "prices", "rise", "opec", "cuts"}) {
+		t.Errorf("Expected lowercase tokens without short and stop words, got %q", got)
+	}
+	// 3 shared tokens out of 6 distinct ones
+	if got := similarity.Lexical("Oil prices spike on supply fears", "Oil prices spike in Europe"); math.Abs(got-3.0/6) > 1e-9 {
+		t.Errorf("Expected a Jaccard similarity of 0.5, got %v", got)
+	}
+	if got := similarity.Lexical("the", "and"); got != 1 {
+		t.Errorf("Expected texts without tokens to be identical, got %v", got)
+	}
+	if got := similarity.Lexical("Airlines cut routes", "Oil prices spike"); got != 0 {
+		t.Errorf("Expected unrelated texts to score 0, got %v", got)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	texts := []string{
+		"Oil prices spike on supply fears",
+		"Airlines cut routes",
+		"Oil prices spike on supply fears in Europe",
+		"Airlines cut routes and jobs",
+	}
+	clusters := similarity.Cluster(texts, similarity.Lexical, 0.6)
+	if len(clusters) != 2 || !slices.Equal(clusters[0], []int{0, 2}) || !slices.Equal(clusters[1], []int{1, 3}) {
+		t.Errorf("Expected the oil and airline predictions clustered, got %v", clusters)
+	}
+	if clusters := similarity.Cluster(texts, similarity.Lexical, 1); len(clusters) != 4 {
+		t.Errorf("Expected no cluster at a threshold of 1, got %v", clusters)
+	}
+}
+
+func TestCosine(t *testing.T) {
+	if got := similarity.Cosine([]float64{1, 1}, []float64{2, 2}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected parallel vectors to score 1, got %v", got)
+	}
+	if got := similarity.Cosine([]float64{1, 0}, []float64{0, 3}); got != 0 {
+		t.Errorf("Expected orthogonal vectors to score 0, got %v", got)
+	}
+	if got := similarity.Cosine([]float64{1, 0}, []float64{1, 1}); math.Abs(got-1/math.Sqrt2) > 1e-9 {
+		t.Errorf("Expected vectors 45 degrees apart to score 1/sqrt(2), got %v", got)
+	}
+	if similarity.Cosine([]float64{0, 0}, []float64{1, 1}) != 0 || similarity.Cosine([]float64{1}, []float64{1, 1}) != 0 {
+		t.Error("Expected 0 for a zero vector or vectors of different lengths")
+	}
+}
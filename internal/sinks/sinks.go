@@ -0,0 +1,152 @@
+package sinks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"nostradamus/internal/models"
+)
+
+// Sink types
+const (
+	// TypeWebhook posts the result as JSON
+	TypeWebhook = "webhook"
+	// TypeSlack posts the result as a Slack incoming webhook message
+	TypeSlack = "slack"
+)
+
+// Types lists the supported sink types
+var Types = []string{TypeWebhook, TypeSlack}
+
+// Headers set on signed deliveries
+const (
+	// TimestampHeader holds the Unix time the delivery was signed at
+	TimestampHeader = "X-Nostradamus-Timestamp"
+	// SignatureHeader holds "sha256=" followed by the hex HMAC-SHA256, keyed by the
+	// sink's secret, of the timestamp, a dot and the body
+	SignatureHeader = "X-Nostradamus-Signature"
+)
+
+// Sink delivers the results of runs
+type Sink interface {
+	Send(r models.CritiquedResponse) error
+}
+
+// Delivery posts payloads to a URL, retrying failed attempts and signing the body when
+// it has a secret
+type Delivery struct {
+	Client *http.Client
+	URL    string
+	// Secret keys the HMAC signature of each delivery; empty leaves them unsigned
+	Secret string
+	// MaxAttempts is the number of attempts before a delivery fails; below 1 means 1
+	MaxAttempts int
+	// RetryDelay is the wait between attempts
+	RetryDelay time.Duration
+}
+
+// Sign returns the signature of body sent at timestamp, as set in SignatureHeader
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post sends body as JSON. Network errors, 429 and 5xx responses are retried; other
+// responses outside 2xx fail at once.
+func (d Delivery) Post(body []byte) error {
+	attempts := max(d.MaxAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(d.RetryDelay)
+		}
+		var retry bool
+		retry, err = d.attempt(body)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("after %d attempts: %w", attempts, err)
+}
+
+// attempt posts body once and reports whether a failure is worth retrying
+func (d Delivery) attempt(body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", d.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, redactURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(d.Secret, timestamp, body))
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, redactURL(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("status %s: %s", resp.Status, bytes.TrimSpace(respBody))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// redactURL masks the URL in an error from building or sending a request, since webhook
+// URLs such as Slack's embed their token
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = "[REDACTED]"
+	}
+	return err
+}
+
+// Webhook posts each result as JSON, in the format of the json output
+type Webhook struct {
+	Delivery Delivery
+}
+
+// NewWebhook creates a sink posting results as JSON through d
+func NewWebhook(d Delivery) *Webhook {
+	return &Webhook{Delivery: d}
+}
+
+// Send posts r
+func (w *Webhook) Send(r models.CritiquedResponse) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return w.Delivery.Post(body)
+}
+
+// New creates the sink of type kind delivering through d
+func New(kind string, d Delivery) (Sink, error) {
+	switch kind {
+	case TypeWebhook:
+		return NewWebhook(d), nil
+	case TypeSlack:
+		return NewSlack(d), nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q, expected %s or %s", kind, TypeWebhook, TypeSlack)
+	}
+}
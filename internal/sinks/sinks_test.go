@@ -0,0 +1,106 @@
+package sinks_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nostradamus/internal/llmtest"
+	"nostradamus/internal/models"
+	"nostradamus/internal/sinks"
+)
+
+func TestWebhookRetriesAndSigns(t *testing.T) {
+	var attempts int32
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer server.Close()
+
+	webhook := sinks.NewWebhook(sinks.Delivery{Client: server.Client(), URL: server.URL, Secret: "s3cret", MaxAttempts: 2, RetryDelay: time.Millisecond})
+	result := models.CritiquedResponse{OriginalPrompt: "x", Predictions: []models.CritiquedPrediction{{Timeframe: "1 month", Description: "d", Impact: "i", Confidence: 0.6, Critique: "c"}}}
+	if err := webhook.Send(result); err != nil {
+		t.Fatalf("Expected the delivery to succeed on retry, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	var delivered models.CritiquedResponse
+	if err := json.Unmarshal(body, &delivered); err != nil || delivered.Predictions[0].Confidence != 0.6 {
+		t.Errorf("Expected the result delivered as JSON, got %s", body)
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("Expected a JSON content type, got %q", header.Get("Content-Type"))
+	}
+	timestamp := header.Get(sinks.TimestampHeader)
+	if timestamp == "" || header.Get(sinks.SignatureHeader) != sinks.Sign("s3cret", timestamp, body) {
+		t.Errorf("Expected a valid signature, got %q at %q", header.Get(sinks.SignatureHeader), timestamp)
+	}
+
+	// Unsigned deliveries carry no signature headers
+	attempts = 1
+	webhook.Delivery.Secret = ""
+	if err := webhook.Send(result); err != nil || header.Get(sinks.SignatureHeader) != "" || header.Get(sinks.TimestampHeader) != "" {
+		t.Errorf("Expected an unsigned delivery, got %v with headers %v", err, header)
+	}
+}
+
+func TestDeliveryFailures(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if r.URL.Path == "/busy" {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, "bad payload", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	// Client errors are not retried
+	d := sinks.Delivery{Client: server.Client(), URL: server.URL, MaxAttempts: 3, RetryDelay: time.Millisecond}
+	if err := d.Post([]byte("{}")); err == nil || !strings.Contains(err.Error(), "bad payload") || attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %d: %v", attempts, err)
+	}
+
+	attempts = 0
+	d.URL = server.URL + "/busy"
+	if err := d.Post([]byte("{}")); err == nil || !strings.Contains(err.Error(), "after 3 attempts") || attempts != 3 {
+		t.Errorf("Expected rate limited deliveries retried, got %d: %v", attempts, err)
+	}
+
+	// Network errors are retried without the URL, which may hold a token
+	attempts = 0
+	client := &http.Client{Transport: llmtest.RoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		return nil, errors.New("connection refused")
+	})}
+	d = sinks.Delivery{Client: client, URL: "https://hooks.example/services/sinktoken", MaxAttempts: 2}
+	if err := d.Post([]byte("{}")); err == nil || strings.Contains(err.Error(), "sinktoken") || attempts != 2 {
+		t.Errorf("Expected a failed delivery without the URL after 2 attempts, got %d: %v", attempts, err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, kind := range sinks.Types {
+		if _, err := sinks.New(kind, sinks.Delivery{}); err != nil {
+			t.Errorf("Expected a %s sink, got %v", kind, err)
+		}
+	}
+	if _, err := sinks.New("teams", sinks.Delivery{}); err == nil {
+		t.Error("Expected an unknown sink type to be rejected")
+	}
+}
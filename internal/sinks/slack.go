@@ -0,0 +1,139 @@
+package sinks
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"nostradamus/internal/models"
+)
+
+// Slack limits on a message
+const (
+	// maxBlocks is the number of blocks a message may have
+	maxBlocks = 50
+	// maxHeader is the length of a header block's text
+	maxHeader = 150
+	// maxSection is the length of a section block's text
+	maxSection = 3000
+)
+
+// Slack posts each result to a Slack incoming webhook, one section per prediction with
+// its confidence drawn as a bar
+type Slack struct {
+	Delivery Delivery
+}
+
+// NewSlack creates a sink posting results to the Slack incoming webhook of d
+func NewSlack(d Delivery) *Slack {
+	return &Slack{Delivery: d}
+}
+
+// Send posts r as a Slack message
+func (s *Slack) Send(r models.CritiquedResponse) error {
+	body, err := json.Marshal(slackMessage(r))
+	if err != nil {
+		return err
+	}
+	return s.Delivery.Post(body)
+}
+
+// text is a Slack text object
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// block is a Slack layout block
+type block struct {
+	Type     string `json:"type"`
+	Text     *text  `json:"text,omitempty"`
+	Elements []text `json:"elements,omitempty"`
+}
+
+// message is the payload of a Slack incoming webhook; Text is shown in notifications
+type message struct {
+	Text   string  `json:"text"`
+	Blocks []block `json:"blocks"`
+}
+
+// slackMessage returns the incoming webhook payload describing r: a header, the run's
+// details and a section per prediction. Predictions beyond Slack's block limit are
+// counted in a closing note.
+func slackMessage(r models.CritiquedResponse) message {
+	m := message{Text: fmt.Sprintf("%d predictions for: %s", len(r.Predictions), r.OriginalPrompt)}
+	m.Blocks = append(m.Blocks, block{Type: "header", Text: &text{Type: "plain_text", Text: truncate("Predictions: "+r.OriginalPrompt, maxHeader)}})
+
+	var details []string
+	if r.AsOf != "" {
+		details = append(details, "As of "+r.AsOf)
+	}
+	if r.Backend != "" {
+		details = append(details, "Critic "+r.Backend)
+	}
+	if r.Calibration != nil {
+		details = append(details, "Calibrated by "+r.Calibration.Method)
+	}
+	if len(details) > 0 {
+		m.Blocks = append(m.Blocks, block{Type: "context", Elements: []text{{Type: "mrkdwn", Text: escape(strings.Join(details, " · "))}}})
+	}
+
+	// Leave a block for the note on predictions left out
+	room := maxBlocks - len(m.Blocks) - 1
+	for i, p := range r.Predictions {
+		if i == room {
+			m.Blocks = append(m.Blocks, block{Type: "context", Elements: []text{{Type: "mrkdwn", Text: fmt.Sprintf("%d more predictions not shown", len(r.Predictions)-room)}}})
+			break
+		}
+		m.Blocks = append(m.Blocks, block{Type: "section", Text: &text{Type: "mrkdwn", Text: truncate(predictionText(p), maxSection)}})
+	}
+	return m
+}
+
+// predictionText describes a prediction in Slack mrkdwn: its timeframe and tags, its
+// confidence bar, its description and its impact
+func predictionText(p models.CritiquedPrediction) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*", escape(p.Timeframe))
+	if p.Direction != "" {
+		fmt.Fprintf(&b, " · %s", escape(p.Direction))
+	}
+	if len(p.Sectors) > 0 {
+		fmt.Fprintf(&b, " · %s", escape(strings.Join(p.Sectors, ", ")))
+	}
+	if len(p.Tickers) > 0 {
+		fmt.Fprintf(&b, " · %s", escape(strings.Join(p.Tickers, ", ")))
+	}
+	fmt.Fprintf(&b, "\n`%s` %.0f%%", ConfidenceBar(p.Confidence), p.Confidence*100)
+	if p.RawConfidence != nil {
+		fmt.Fprintf(&b, " (critic said %.0f%%)", *p.RawConfidence*100)
+	}
+	fmt.Fprintf(&b, "\n%s\n_Impact:_ %s", escape(p.Description), escape(p.Impact))
+	return b.String()
+}
+
+// barWidth is the number of cells of a confidence bar
+const barWidth = 10
+
+// ConfidenceBar draws confidence as a bar of filled and empty cells, rounded to the
+// nearest cell
+func ConfidenceBar(confidence float64) string {
+	filled := int(confidence*barWidth + 0.5)
+	filled = min(max(filled, 0), barWidth)
+	return strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+}
+
+// escape replaces the characters Slack reserves for links and mentions
+func escape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis when cut
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,85 @@
+package sinks_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/models"
+	"nostradamus/internal/sinks"
+)
+
+func TestSlack(t *testing.T) {
+	var message struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Text string `json:"text"`
+			} `json:"text"`
+			Elements []struct {
+				Text string `json:"text"`
+			} `json:"elements"`
+		} `json:"blocks"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&message)
+	}))
+	defer server.Close()
+
+	raw := 0.9
+	result := models.CritiquedResponse{OriginalPrompt: "Oil <shock>", AsOf: "2026-10-19", Backend: "openai/gpt-4o", Predictions: []models.CritiquedPrediction{
+		{Timeframe: "1 month", Description: "Oil rises & <spreads>", Impact: "Energy rallies", Direction: "bullish", Sectors: []string{"Energy"}, Confidence: 0.7, RawConfidence: &raw, Critique: "c"},
+	}}
+	for i := 0; i < 60; i++ {
+		result.Predictions = append(result.Predictions, models.CritiquedPrediction{Timeframe: "1 year", Description: "d", Impact: "i", Confidence: 0.1, Critique: "c"})
+	}
+	slack := sinks.NewSlack(sinks.Delivery{Client: server.Client(), URL: server.URL})
+	if err := slack.Send(result); err != nil {
+		t.Fatalf("Expected the Slack delivery to succeed, got %v", err)
+	}
+	if len(message.Blocks) != 50 || message.Blocks[0].Type != "header" || message.Blocks[49].Type != "context" {
+		t.Fatalf("Expected 50 blocks starting with a header and ending with a note, got %d", len(message.Blocks))
+	}
+	if got := message.Blocks[1].Elements[0].Text; got != "As of 2026-10-19 · Critic openai/gpt-4o" {
+		t.Errorf("Unexpected details %q", got)
+	}
+	section := message.Blocks[2].Text.Text
+	if !strings.Contains(section, "`███████░░░` 70% (critic said 90%)") || !strings.Contains(section, "*1 month* · bullish · Energy") {
+		t.Errorf("Unexpected prediction section %q", section)
+	}
+	if !strings.Contains(section, "Oil rises &amp; &lt;spreads&gt;") {
+		t.Errorf("Expected the description escaped, got %q", section)
+	}
+	if got := message.Blocks[49].Elements[0].Text; got != "14 more predictions not shown" {
+		t.Errorf("Unexpected closing note %q", got)
+	}
+	if message.Text != "61 predictions for: Oil <shock>" || message.Blocks[0].Text.Text != "Predictions: Oil <shock>" {
+		t.Errorf("Unexpected message text %q, header %q", message.Text, message.Blocks[0].Text.Text)
+	}
+
+	result = models.CritiquedResponse{OriginalPrompt: strings.Repeat("x", 200)}
+	if err := slack.Send(result); err != nil {
+		t.Fatal(err)
+	}
+	if header := []rune(message.Blocks[0].Text.Text); len(header) != 150 || header[149] != '…' {
+		t.Errorf("Expected the header cut to 150 characters, got %d", len(header))
+	}
+}
+
+func TestConfidenceBar(t *testing.T) {
+	for confidence, want := range map[float64]string{
+		0.04: "░░░░░░░░░░",
+		0.05: "█░░░░░░░░░",
+		0.7:  "███████░░░",
+		1:    "██████████",
+		1.5:  "██████████",
+		-0.2: "░░░░░░░░░░",
+	} {
+		if got := sinks.ConfidenceBar(confidence); got != want {
+			t.Errorf("ConfidenceBar(%v) = %q, expected %q", confidence, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// MemoryExporter keeps exported spans in memory, for tests
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// Export appends spans to the exporter
+func (e *MemoryExporter) Export(spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Spans returns a copy of every span exported so far
+func (e *MemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// FileExporter appends each trace to a file as one line of OTLP/JSON
+type FileExporter struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileExporter creates an exporter appending to the file at path
+func NewFileExporter(path string) *FileExporter {
+	return &FileExporter{path: path}
+}
+
+// Export appends spans to the file as an OTLP/JSON line
+func (e *FileExporter) Export(spans []SpanData) error {
+	encoded, err := EncodeOTLP(spans)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	f, err := os.OpenFile(e.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(encoded, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// HTTPExporter posts each trace as OTLP/JSON to a collector, such as
+// http://localhost:4318/v1/traces
+type HTTPExporter struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+// NewHTTPExporter creates an exporter posting to endpoint with httpClient
+func NewHTTPExporter(endpoint string, httpClient *http.Client) *HTTPExporter {
+	return &HTTPExporter{endpoint: endpoint, httpClient: httpClient}
+}
+
+// Export posts spans to the collector
+func (e *HTTPExporter) Export(spans []SpanData) error {
+	encoded, err := EncodeOTLP(spans)
+	if err != nil {
+		return err
+	}
+	resp, err := e.httpClient.Post(e.endpoint, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// The types below mirror the OTLP/JSON encoding of an ExportTraceServiceRequest
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code StatusCode `json:"code,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// serviceName identifies Nostradamus as the resource emitting the spans
+const serviceName = "nostradamus"
+
+// EncodeOTLP encodes spans as an OTLP/JSON ExportTraceServiceRequest
+func EncodeOTLP(spans []SpanData) ([]byte, error) {
+	encoded := make([]otlpSpan, len(spans))
+	for i, s := range spans {
+		encoded[i] = otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status},
+		}
+	}
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: encodeAttributes(map[string]any{"service.name": serviceName})},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: serviceName}, Spans: encoded}},
+	}}}
+	return json.Marshal(request)
+}
+
+// encodeAttributes converts attributes to OTLP key-values, sorted by key
+func encodeAttributes(attributes map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	encoded := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		encoded = append(encoded, otlpKeyValue{Key: k, Value: encodeValue(attributes[k])})
+	}
+	return encoded
+}
+
+// encodeValue converts a Go value to an OTLP AnyValue, falling back to its string form
+func encodeValue(v any) otlpAnyValue {
+	switch value := v.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &value}
+	case bool:
+		return otlpAnyValue{BoolValue: &value}
+	case int:
+		s := strconv.Itoa(value)
+		return otlpAnyValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(value, 10)
+		return otlpAnyValue{IntValue: &s}
+	case float64:
+		return otlpAnyValue{DoubleValue: &value}
+	default:
+		s := fmt.Sprint(value)
+		return otlpAnyValue{StringValue: &s}
+	}
+}
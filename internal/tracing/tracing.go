@@ -0,0 +1,190 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Kind tells whether a span is internal work or a call to a remote service
+type Kind int
+
+const (
+	// KindInternal is an operation within Nostradamus
+	KindInternal Kind = 1
+	// KindClient is an outgoing request, such as an HTTP call to the LLM API
+	KindClient Kind = 3
+)
+
+// StatusCode is the outcome of a span
+type StatusCode int
+
+const (
+	// StatusUnset means the span ended without reporting an outcome
+	StatusUnset StatusCode = 0
+	// StatusOK means the operation succeeded
+	StatusOK StatusCode = 1
+	// StatusError means the operation failed
+	StatusError StatusCode = 2
+)
+
+// SpanData is the immutable record of an ended span handed to exporters
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Kind         Kind
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]any
+	Status       StatusCode
+}
+
+// Exporter receives the spans of a trace once its root span has ended
+type Exporter interface {
+	Export(spans []SpanData) error
+}
+
+// Tracer creates spans and hands each finished trace to its exporter
+type Tracer struct {
+	exporter Exporter
+
+	mu      sync.Mutex
+	pending map[string][]SpanData
+}
+
+// NewTracer creates a Tracer exporting to exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter, pending: make(map[string][]SpanData)}
+}
+
+// Start begins the root span of a new trace. A nil Tracer returns a nil Span, whose
+// methods do nothing, so callers never need to check whether tracing is enabled.
+func (t *Tracer) Start(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	return &Span{tracer: t, data: SpanData{
+		TraceID:    newID(16),
+		SpanID:     newID(8),
+		Name:       name,
+		Kind:       KindInternal,
+		Start:      time.Now(),
+		Attributes: make(map[string]any),
+	}}
+}
+
+// finish records an ended span and exports the trace when its root span ends
+func (t *Tracer) finish(data SpanData) error {
+	t.mu.Lock()
+	t.pending[data.TraceID] = append(t.pending[data.TraceID], data)
+	if data.ParentSpanID != "" {
+		t.mu.Unlock()
+		return nil
+	}
+	spans := t.pending[data.TraceID]
+	delete(t.pending, data.TraceID)
+	t.mu.Unlock()
+	return t.exporter.Export(spans)
+}
+
+// Span is a timed operation within a trace
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// Child begins a span nested under s
+func (s *Span) Child(name string) *Span {
+	return s.child(name, KindInternal)
+}
+
+// ClientChild begins a span nested under s for an outgoing request
+func (s *Span) ClientChild(name string) *Span {
+	return s.child(name, KindClient)
+}
+
+func (s *Span) child(name string, kind Kind) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{tracer: s.tracer, data: SpanData{
+		TraceID:      s.data.TraceID,
+		SpanID:       newID(8),
+		ParentSpanID: s.data.SpanID,
+		Name:         name,
+		Kind:         kind,
+		Start:        time.Now(),
+		Attributes:   make(map[string]any),
+	}}
+}
+
+// TraceID returns the identifier of the trace s belongs to
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.data.TraceID
+}
+
+// SetAttribute records a string, bool, integer or float attribute on s
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// SetError marks s as failed when err is set and as successful otherwise. The error text
+// is not recorded, since it can carry API response bodies and keys that exporters would
+// write out unredacted.
+func (s *Span) SetError(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.data.Status = StatusError
+		return
+	}
+	s.data.Status = StatusOK
+}
+
+// End closes s. Ending the root span exports the whole trace and returns the export error.
+func (s *Span) End() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return nil
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	attributes := make(map[string]any, len(s.data.Attributes))
+	for k, v := range s.data.Attributes {
+		attributes[k] = v
+	}
+	data.Attributes = attributes
+	s.mu.Unlock()
+	return s.tracer.finish(data)
+}
+
+// newID returns n random bytes as hex, the format OTLP uses for trace and span IDs
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
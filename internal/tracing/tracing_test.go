@@ -0,0 +1,147 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"nostradamus/internal/tracing"
+)
+
+func TestSpanTree(t *testing.T) {
+	exporter := &tracing.MemoryExporter{}
+	tracer := tracing.NewTracer(exporter)
+
+	root := tracer.Start("run")
+	stage := root.Child("critique")
+	call := stage.ClientChild("POST")
+	call.SetAttribute("http.response.status_code", 502)
+	call.SetError(errors.New("API returned status 502: sk-proxy-secret"))
+	call.End()
+	stage.SetError(nil)
+	stage.End()
+	if len(exporter.Spans()) != 0 {
+		t.Fatalf("Expected nothing exported before the root span ends, got: %+v", exporter.Spans())
+	}
+	if err := root.End(); err != nil {
+		t.Fatalf("Failed to export trace: %v", err)
+	}
+	root.End()
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans exported once, got: %+v", spans)
+	}
+	byName := make(map[string]tracing.SpanData)
+	for _, s := range spans {
+		byName[s.Name] = s
+		if s.TraceID != root.TraceID() || len(s.TraceID) != 32 || len(s.SpanID) != 16 {
+			t.Errorf("Expected hex IDs in the root's trace, got: %+v", s)
+		}
+	}
+	if byName["run"].ParentSpanID != "" || byName["critique"].ParentSpanID != byName["run"].SpanID || byName["POST"].ParentSpanID != byName["critique"].SpanID {
+		t.Errorf("Expected POST nested in critique nested in run, got: %+v", spans)
+	}
+	if byName["POST"].Kind != tracing.KindClient || byName["critique"].Kind != tracing.KindInternal {
+		t.Errorf("Expected client and internal span kinds, got: %+v", spans)
+	}
+	if byName["POST"].Status != tracing.StatusError || byName["critique"].Status != tracing.StatusOK || byName["run"].Status != tracing.StatusUnset {
+		t.Errorf("Expected error, ok and unset statuses, got: %+v", spans)
+	}
+	if byName["POST"].Attributes["http.response.status_code"] != 502 {
+		t.Errorf("Expected the status code attribute, got: %+v", byName["POST"].Attributes)
+	}
+
+	// A nil tracer hands out nil spans whose methods do nothing
+	var disabled *tracing.Tracer
+	span := disabled.Start("run")
+	span.Child("critique").SetAttribute("attempts", 1)
+	if err := span.End(); err != nil || span.TraceID() != "" {
+		t.Errorf("Expected a no-op span, got: %v %q", err, span.TraceID())
+	}
+}
+
+func TestEncodeOTLP(t *testing.T) {
+	exporter := &tracing.MemoryExporter{}
+	root := tracing.NewTracer(exporter).Start("run")
+	root.SetAttribute("input", "test event")
+	root.SetAttribute("attempts", 2)
+	root.SetAttribute("confidence", 0.5)
+	root.SetAttribute("stabilised", true)
+	root.SetError(errors.New("critique: sk-proxy-secret"))
+	root.End()
+
+	encoded, err := tracing.EncodeOTLP(exporter.Spans())
+	if err != nil {
+		t.Fatalf("Failed to encode OTLP: %v", err)
+	}
+	var otlp struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID           string `json:"traceId"`
+					StartTimeUnixNano string `json:"startTimeUnixNano"`
+					Attributes        []struct {
+						Key   string                 `json:"key"`
+						Value map[string]interface{} `json:"value"`
+					} `json:"attributes"`
+					Status struct {
+						Code    int    `json:"code"`
+						Message string `json:"message"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(encoded, &otlp); err != nil {
+		t.Fatalf("Failed to parse OTLP: %v", err)
+	}
+	spans := otlp.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || len(spans[0].TraceID) != 32 || spans[0].StartTimeUnixNano == "" {
+		t.Fatalf("Expected an OTLP/JSON span with a hex trace ID, got: %s", encoded)
+	}
+	values := make(map[string]interface{})
+	for _, a := range spans[0].Attributes {
+		for k, v := range a.Value {
+			values[a.Key+"."+k] = v
+		}
+	}
+	if values["attempts.intValue"] != "2" || values["confidence.doubleValue"] != 0.5 || values["stabilised.boolValue"] != true || values["input.stringValue"] != "test event" {
+		t.Errorf("Expected typed OTLP attribute values, got: %v", values)
+	}
+	if spans[0].Status.Code != int(tracing.StatusError) || strings.Contains(string(encoded), "sk-proxy-secret") {
+		t.Errorf("Expected an error status without the error text, got: %s", encoded)
+	}
+}
+
+func TestHTTPExporter(t *testing.T) {
+	var received []byte
+	status := http.StatusOK
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected OTLP/JSON, got: %s", r.Header.Get("Content-Type"))
+		}
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		w.Write([]byte("collector says no"))
+	}))
+	defer collector.Close()
+
+	tracer := tracing.NewTracer(tracing.NewHTTPExporter(collector.URL, collector.Client()))
+	if err := tracer.Start("run").End(); err != nil {
+		t.Fatalf("Failed to export trace: %v", err)
+	}
+	if !strings.Contains(string(received), `"resourceSpans"`) || !strings.Contains(string(received), `"name":"run"`) {
+		t.Errorf("Expected the trace posted as OTLP/JSON, got: %s", received)
+	}
+
+	status = http.StatusBadRequest
+	err := tracer.Start("run").End()
+	if err == nil || !strings.Contains(err.Error(), "status 400: collector says no") {
+		t.Errorf("Expected the collector's rejection, got: %v", err)
+	}
+}